@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ALBHandler adapts h to Application Load Balancer target groups. When the
+// target group has multi-value headers enabled the load balancer sends only
+// the multi-value maps and expects them back, so the response mirrors the
+// request's representation. Without multi-value headers a response can set
+// only one cookie, so only the last is kept.
+func ALBHandler(h HandlerFunc) func(context.Context, events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return func(ctx context.Context, request events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+		markInvocation()
+		response, err := h(ctx, fromALBRequest(request))
+		return toALBResponse(response, len(request.MultiValueHeaders) > 0), err
+	}
+}
+
+func fromALBRequest(request events.ALBTargetGroupRequest) events.APIGatewayProxyRequest {
+	headers := request.Headers
+	if len(headers) == 0 && len(request.MultiValueHeaders) > 0 {
+		headers = lastValues(request.MultiValueHeaders)
+	}
+
+	// The load balancer passes query strings through without decoding them.
+	multiValueQuery := make(map[string][]string, len(request.MultiValueQueryStringParameters))
+	for k, vs := range request.MultiValueQueryStringParameters {
+		for _, v := range vs {
+			multiValueQuery[unescapeQuery(k)] = append(multiValueQuery[unescapeQuery(k)], unescapeQuery(v))
+		}
+	}
+	for k, v := range request.QueryStringParameters {
+		if _, ok := multiValueQuery[unescapeQuery(k)]; !ok {
+			multiValueQuery[unescapeQuery(k)] = []string{unescapeQuery(v)}
+		}
+	}
+
+	// The load balancer appends the address it saw to whatever the client
+	// sent, so only the rightmost entry can be trusted.
+	var sourceIP string
+	if forwarded := headerValue(headers, "X-Forwarded-For"); forwarded != "" {
+		sourceIP = strings.TrimSpace(forwarded[strings.LastIndex(forwarded, ",")+1:])
+	}
+
+	return events.APIGatewayProxyRequest{
+		Path:                            request.Path,
+		HTTPMethod:                      request.HTTPMethod,
+		Headers:                         headers,
+		MultiValueHeaders:               request.MultiValueHeaders,
+		QueryStringParameters:           lastValues(multiValueQuery),
+		MultiValueQueryStringParameters: multiValueQuery,
+		Body:                            request.Body,
+		IsBase64Encoded:                 request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Path:       request.Path,
+			HTTPMethod: request.HTTPMethod,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIP,
+				UserAgent: headerValue(headers, "User-Agent"),
+			},
+		},
+	}
+}
+
+func toALBResponse(response Response, multiValue bool) events.ALBTargetGroupResponse {
+	headers := make(map[string]string, len(response.Headers)+1)
+	for k, v := range response.Headers {
+		headers[k] = v
+	}
+	// The load balancer rejects responses without a Content-Type.
+	if headerValue(headers, "Content-Type") == "" {
+		headers["Content-Type"] = "application/json"
+	}
+
+	alb := events.ALBTargetGroupResponse{
+		StatusCode:        response.StatusCode,
+		StatusDescription: statusDescription(response.StatusCode),
+		Body:              response.Body,
+		IsBase64Encoded:   response.IsBase64Encoded,
+	}
+	if multiValue {
+		alb.MultiValueHeaders = make(map[string][]string, len(headers))
+		for k, v := range headers {
+			alb.MultiValueHeaders[k] = []string{v}
+		}
+		if len(response.Cookies) > 0 {
+			alb.MultiValueHeaders["Set-Cookie"] = append(alb.MultiValueHeaders["Set-Cookie"], response.Cookies...)
+		}
+	} else {
+		if n := len(response.Cookies); n > 0 {
+			if n > 1 {
+				logger.Warn("dropping cookies: enable multi-value headers on the target group to set more than one",
+					"cookies", n)
+			}
+			headers["Set-Cookie"] = response.Cookies[n-1]
+		}
+		alb.Headers = headers
+	}
+	return alb
+}
+
+// statusDescription renders the status line the load balancer expects,
+// e.g. "404 Not Found".
+func statusDescription(statusCode int) string {
+	return strings.TrimSpace(fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)))
+}
+
+func unescapeQuery(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+func lastValues(multiValue map[string][]string) map[string]string {
+	if len(multiValue) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(multiValue))
+	for k, vs := range multiValue {
+		if len(vs) > 0 {
+			values[k] = vs[len(vs)-1]
+		}
+	}
+	return values
+}
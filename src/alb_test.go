@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestFromALBRequestSourceIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded string
+		want      string
+	}{
+		{"none", "", ""},
+		{"single", "203.0.113.7", "203.0.113.7"},
+		{"spoofed prefix", "198.51.100.1, 203.0.113.7", "203.0.113.7"},
+		{"padded", "198.51.100.1 ,  203.0.113.7 ", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.ALBTargetGroupRequest{HTTPMethod: "GET", Path: "/"}
+			if tt.forwarded != "" {
+				request.Headers = map[string]string{"x-forwarded-for": tt.forwarded}
+			}
+			if got := fromALBRequest(request).RequestContext.Identity.SourceIP; got != tt.want {
+				t.Errorf("SourceIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// alertTimeout bounds each publish, so a slow target cannot hold alerts
+// back for later invocations.
+const alertTimeout = 2 * time.Second
+
+// alertDetailType is the detail-type, and SNS subject, of every alert.
+const alertDetailType = "Lambda Server Error"
+
+// AlertConfig sets where server errors are reported. With neither target
+// set, alerting is off.
+type AlertConfig struct {
+	// SNSTopicARN is the topic alerts are published to.
+	SNSTopicARN string
+	// EventBusName is the EventBridge bus alerts are put on, with source
+	// Source.
+	EventBusName string
+	Source       string
+	// MinInterval is the least time between two alerts from one container;
+	// errors in between are counted and reported with the next alert.
+	MinInterval time.Duration
+}
+
+// DefaultAlertConfig leaves alerting off, allowing one alert a minute per
+// container once a target is set.
+func DefaultAlertConfig() AlertConfig {
+	return AlertConfig{Source: "go-lambda", MinInterval: time.Minute}
+}
+
+// Alert describes one server error.
+type Alert struct {
+	Function   string    `json:"function"`
+	RequestID  string    `json:"requestId"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+	Panic      bool      `json:"panic,omitempty"`
+	Time       time.Time `json:"time"`
+	// Suppressed counts the errors since the previous alert that were not
+	// sent on their own because of the minimum interval.
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Alerter publishes alerts to SNS, EventBridge or both. It is safe for
+// concurrent use.
+type Alerter struct {
+	cfg     AlertConfig
+	targets []func(ctx context.Context, body []byte) error
+
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// newAlerter builds the alerter configured by cfg, or returns nil when no
+// target is set.
+func newAlerter(ctx context.Context, cfg AlertConfig) (*Alerter, error) {
+	if cfg.SNSTopicARN == "" && cfg.EventBusName == "" {
+		return nil, nil
+	}
+
+	a := &Alerter{cfg: cfg}
+	if cfg.SNSTopicARN != "" {
+		client, err := awsClients.SNS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.targets = append(a.targets, func(ctx context.Context, body []byte) error {
+			_, err := client.Publish(ctx, &sns.PublishInput{
+				TopicArn: aws.String(cfg.SNSTopicARN),
+				Subject:  aws.String(alertDetailType),
+				Message:  aws.String(string(body)),
+			})
+			return err
+		})
+	}
+	if cfg.EventBusName != "" {
+		client, err := awsClients.EventBridge(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.targets = append(a.targets, func(ctx context.Context, body []byte) error {
+			out, err := client.PutEvents(ctx, &eventbridge.PutEventsInput{
+				Entries: []ebtypes.PutEventsRequestEntry{{
+					EventBusName: aws.String(cfg.EventBusName),
+					Source:       aws.String(cfg.Source),
+					DetailType:   aws.String(alertDetailType),
+					Detail:       aws.String(string(body)),
+				}},
+			})
+			if err == nil && out.FailedEntryCount > 0 {
+				err = fmt.Errorf("event rejected: %s", aws.ToString(out.Entries[0].ErrorMessage))
+			}
+			return err
+		})
+	}
+	return a, nil
+}
+
+// Send publishes alert in the background and returns at once, so the
+// response is never delayed. Within MinInterval of the previous alert it is
+// only counted. Lambda freezes the container once the response is returned,
+// so a publish still in flight finishes at the next invocation, or not at
+// all; delivery is best-effort.
+func (a *Alerter) Send(ctx context.Context, alert Alert) {
+	now := time.Now()
+	a.mu.Lock()
+	if !a.last.IsZero() && now.Sub(a.last) < a.cfg.MinInterval {
+		a.suppressed++
+		a.mu.Unlock()
+		return
+	}
+	a.last = now
+	alert.Suppressed, a.suppressed = a.suppressed, 0
+	a.mu.Unlock()
+
+	alert.Function = lambdacontext.FunctionName
+	alert.Time = now
+	body, err := json.Marshal(alert)
+	if err != nil {
+		logger.ErrorContext(ctx, "encoding alert", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), alertTimeout)
+	var wg sync.WaitGroup
+	for _, publish := range a.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := publish(ctx, body); err != nil {
+				logger.ErrorContext(ctx, "publishing alert", "error", err)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+}
+
+// AlertMiddleware sends an alert for each request that ends in a 5xx,
+// whether returned as a response or as an error that maps to one, and for
+// each panic, which it re-raises for RecoverMiddleware to answer. It goes
+// just inside ErrorMappingMiddleware to see errors before they are mapped.
+// A nil alerter passes requests through untouched.
+func AlertMiddleware(alerter *Alerter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		if alerter == nil {
+			return next
+		}
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			alert := Alert{
+				RequestID: RequestIDFromContext(ctx),
+				Method:    request.HTTPMethod,
+				Path:      request.Path,
+			}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					alert.StatusCode = 500
+					alert.Error = fmt.Sprint(recovered)
+					alert.Panic = true
+					alerter.Send(ctx, alert)
+					panic(recovered)
+				}
+			}()
+
+			response, err := next(ctx, request)
+			switch {
+			case err != nil:
+				alert.StatusCode = ErrorResponse(err).StatusCode
+				alert.Error = err.Error()
+			case response.StatusCode >= 500:
+				alert.StatusCode = response.StatusCode
+			}
+			if alert.StatusCode >= 500 {
+				alerter.Send(ctx, alert)
+			}
+			return response, err
+		}
+	}
+}
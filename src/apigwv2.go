@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HTTPAPIHandler adapts h to API Gateway HTTP APIs (payload format 2.0).
+// Requests are normalized into the REST API (v1) shape used internally, so
+// the same handlers serve both API types. Cookies are returned in the
+// response's cookies list, which payload v2 uses for Set-Cookie.
+func HTTPAPIHandler(h HandlerFunc) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		markInvocation()
+		response, err := h(ctx, fromV2Request(request))
+		cookies, headers := responseCookies(response)
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode:      response.StatusCode,
+			Headers:         headers,
+			Body:            response.Body,
+			IsBase64Encoded: response.IsBase64Encoded,
+			Cookies:         cookies,
+		}, err
+	}
+}
+
+func fromV2Request(request events.APIGatewayV2HTTPRequest) events.APIGatewayProxyRequest {
+	headers, multiValueHeaders := normalizeV2Headers(request.Headers, request.Cookies)
+	query, multiValueQuery := normalizeV2Query(request.RawQueryString, request.QueryStringParameters)
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        request.RouteKey,
+		Path:                            request.RawPath,
+		HTTPMethod:                      request.RequestContext.HTTP.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               multiValueHeaders,
+		QueryStringParameters:           query,
+		MultiValueQueryStringParameters: multiValueQuery,
+		PathParameters:                  request.PathParameters,
+		StageVariables:                  request.StageVariables,
+		Body:                            request.Body,
+		IsBase64Encoded:                 request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        request.RequestContext.AccountID,
+			Stage:            request.RequestContext.Stage,
+			DomainName:       request.RequestContext.DomainName,
+			DomainPrefix:     request.RequestContext.DomainPrefix,
+			RequestID:        request.RequestContext.RequestID,
+			Protocol:         request.RequestContext.HTTP.Protocol,
+			Path:             request.RequestContext.HTTP.Path,
+			HTTPMethod:       request.RequestContext.HTTP.Method,
+			RequestTime:      request.RequestContext.Time,
+			RequestTimeEpoch: request.RequestContext.TimeEpoch,
+			APIID:            request.RequestContext.APIID,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  request.RequestContext.HTTP.SourceIP,
+				UserAgent: request.RequestContext.HTTP.UserAgent,
+			},
+		},
+	}
+}
+
+// normalizeV2Headers rebuilds the v1 header maps. Payload v2 joins repeated
+// headers with commas and moves cookies into a separate list, so cookies are
+// restored as a single Cookie header.
+func normalizeV2Headers(in map[string]string, cookies []string) (map[string]string, map[string][]string) {
+	headers := make(map[string]string, len(in)+1)
+	multiValue := make(map[string][]string, len(in)+1)
+	for k, v := range in {
+		headers[k] = v
+		multiValue[k] = []string{v}
+	}
+	if len(cookies) > 0 {
+		headers["cookie"] = strings.Join(cookies, "; ")
+		multiValue["cookie"] = []string{headers["cookie"]}
+	}
+	return headers, multiValue
+}
+
+// normalizeV2Query rebuilds the v1 query maps. Repeated keys are recovered
+// from the raw query string, since payload v2 comma-joins them.
+func normalizeV2Query(raw string, in map[string]string) (map[string]string, map[string][]string) {
+	values, err := url.ParseQuery(raw)
+	if err != nil || len(values) == 0 {
+		if len(in) == 0 {
+			return nil, nil
+		}
+		multiValue := make(map[string][]string, len(in))
+		for k, v := range in {
+			multiValue[k] = strings.Split(v, ",")
+		}
+		return in, multiValue
+	}
+
+	query := make(map[string]string, len(values))
+	for k, v := range values {
+		query[k] = v[len(v)-1]
+	}
+	return query, values
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type apiKeyIDKey struct{}
+
+// APIKeyIDFromContext returns the identifier of the API key that
+// authenticated the request, or "" when none did.
+func APIKeyIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return id
+}
+
+// APIKeySource returns the API keys, an identifier -> key map, accepted for
+// a request. It is consulted on every request, so keys can rotate without a
+// cold start.
+type APIKeySource func(ctx context.Context) map[string]string
+
+// StaticAPIKeys returns a source that always accepts keys.
+func StaticAPIKeys(keys map[string]string) APIKeySource {
+	return func(context.Context) map[string]string { return keys }
+}
+
+// newAPIKeySource returns the keys configured by API_KEYS joined by those in
+// the secret's "apiKeys" object, which win on a shared identifier. The
+// secret is read through secrets on every call, so rotated keys apply once
+// the loader refreshes. secrets may be nil.
+func newAPIKeySource(static map[string]string, secrets *SecretsLoader) APIKeySource {
+	if secrets == nil {
+		return StaticAPIKeys(static)
+	}
+	return func(ctx context.Context) map[string]string {
+		rotated, ok := secrets.StringMap(ctx, "apiKeys")
+		if !ok {
+			return static
+		}
+		keys := make(map[string]string, len(static)+len(rotated))
+		for id, key := range static {
+			keys[id] = key
+		}
+		for id, key := range rotated {
+			keys[id] = key
+		}
+		return keys
+	}
+}
+
+// matchAPIKey returns the identifier of the key in keys (identifier -> key)
+// equal to presented. Every key is compared in constant time, and the loop
+// never exits early, so response timing reveals nothing about the keys.
+func matchAPIKey(keys map[string]string, presented string) (string, bool) {
+	var matched string
+	for id, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(presented)) == 1 {
+			matched = id
+		}
+	}
+	return matched, matched != ""
+}
+
+// APIKeyMiddleware requires an X-Api-Key header matching one of the keys
+// from keys, read per request, and records the key's identifier in the
+// context. Apply it per route with Chain so public routes stay ungated.
+func APIKeyMiddleware(keys APIKeySource) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			presented := GetHeader(request, "X-Api-Key")
+			if presented != "" {
+				if id, ok := matchAPIKey(keys(ctx), presented); ok {
+					return next(context.WithValue(ctx, apiKeyIDKey{}, id), request)
+				}
+			}
+
+			logger.WarnContext(ctx, "rejecting request without a valid API key", "keyPresent", presented != "")
+			return ErrorResponse(NewAPIError(401, "invalid_api_key", "A valid X-Api-Key header is required")), nil
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAPIKeyMiddlewareFollowsRotation(t *testing.T) {
+	secrets := &SecretsLoader{
+		values:  map[string]interface{}{"apiKeys": map[string]interface{}{"billing": "old-key"}},
+		fetched: time.Now(),
+	}
+	h := APIKeyMiddleware(newAPIKeySource(map[string]string{"ops": "ops-key"}, secrets))(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, map[string]string{"id": APIKeyIDFromContext(ctx)}), nil
+	})
+	call := func(key string) Response {
+		t.Helper()
+		response, err := h(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Api-Key": key}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	for _, key := range []string{"ops-key", "old-key"} {
+		if response := call(key); response.StatusCode != 200 {
+			t.Errorf("%s got %d before rotation, want 200", key, response.StatusCode)
+		}
+	}
+
+	// A refresh of the loader replaces its values, as load does.
+	secrets.mu.Lock()
+	secrets.values = map[string]interface{}{"apiKeys": map[string]interface{}{"billing": "new-key"}}
+	secrets.mu.Unlock()
+
+	for key, want := range map[string]int{"ops-key": 200, "new-key": 200, "old-key": 401} {
+		if response := call(key); response.StatusCode != want {
+			t.Errorf("%s got %d after rotation, want %d", key, response.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig identifies the identity provider whose tokens are accepted.
+type JWTConfig struct {
+	JWKSURL  string
+	Issuer   string
+	Audience string
+}
+
+// JWTAuthenticator validates bearer tokens against a provider's JWKS. Keys
+// are cached and refreshed in the background, and an unknown key ID
+// triggers a rate-limited refetch so rotations are picked up.
+type JWTAuthenticator struct {
+	keys   keyfunc.Keyfunc
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator fetches the JWKS at cfg.JWKSURL and returns an
+// authenticator checking the signature, exp, iss and aud of each token.
+func NewJWTAuthenticator(ctx context.Context, cfg JWTConfig) (*JWTAuthenticator, error) {
+	keys, err := keyfunc.NewDefaultCtx(ctx, []string{cfg.JWKSURL})
+	if err != nil {
+		return nil, fmt.Errorf("loading JWKS from %s: %w", cfg.JWKSURL, err)
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "EdDSA"}),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	return &JWTAuthenticator{keys: keys, parser: jwt.NewParser(options...)}, nil
+}
+
+// Authenticate validates token and returns its claims.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keys.KeyfuncCtx(ctx)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token authenticated by
+// AuthMiddleware, or false for unauthenticated requests.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+var errMissingBearerToken = errors.New("missing bearer token")
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(request events.APIGatewayProxyRequest) (string, error) {
+	scheme, token, ok := strings.Cut(GetHeader(request, "Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", errMissingBearerToken
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// AuthMiddleware requires a valid bearer token and stores its claims in the
+// context. Any failure returns a 401 without running next.
+func AuthMiddleware(auth *JWTAuthenticator) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			token, err := bearerToken(request)
+			if err == nil {
+				var claims jwt.MapClaims
+				if claims, err = auth.Authenticate(ctx, token); err == nil {
+					return next(context.WithValue(ctx, claimsKey{}, claims), request)
+				}
+			}
+
+			logger.WarnContext(ctx, "rejecting unauthenticated request", "error", err)
+			response := ErrorResponse(ErrUnauthorized)
+			response.Headers["WWW-Authenticate"] = `Bearer error="invalid_token"`
+			return response, nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// errAuthorizerUnauthorized is the error API Gateway turns into a 401 when a
+// Lambda authorizer returns it, for requests without credentials.
+var errAuthorizerUnauthorized = errors.New("Unauthorized")
+
+// authorizer is the Lambda authorizer configured by main, used by the
+// authorizer build tag.
+var authorizer *Authorizer
+
+// Authorizer is an API Gateway REQUEST-type Lambda authorizer accepting the
+// same credentials as AuthMiddleware and APIKeyMiddleware: a bearer token
+// validated against the JWKS, or an X-Api-Key header matching one of the
+// configured keys.
+type Authorizer struct {
+	jwt     *JWTAuthenticator
+	apiKeys APIKeySource
+}
+
+// NewAuthorizer returns an authorizer checking bearer tokens with jwt and API
+// keys against those apiKeys returns, read per request. Either may be nil to
+// turn that kind of credential off.
+func NewAuthorizer(jwt *JWTAuthenticator, apiKeys APIKeySource) *Authorizer {
+	return &Authorizer{jwt: jwt, apiKeys: apiKeys}
+}
+
+// Handle authorizes one request. Valid credentials get a policy allowing
+// every method and path of the stage, rather than just the method ARN, so
+// API Gateway can cache it by identity source and reuse it across routes; the
+// principal ID is the token's subject or the API key's identifier. context
+// reaches the backend handler as requestContext.authorizer.
+//
+// Invalid credentials get a deny policy, which API Gateway answers with a
+// 403, and a request presenting none gets a 401.
+func (a *Authorizer) Handle(ctx context.Context, request events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+	markInvocation()
+	ctx = withRequestIDs(ctx, request.RequestContext.RequestID)
+	resource := stageWildcardARN(request.MethodArn)
+
+	authorization := headerValue(request.Headers, "Authorization")
+	if a.jwt != nil && authorization != "" {
+		scheme, token, _ := strings.Cut(authorization, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			claims, err := a.jwt.Authenticate(ctx, strings.TrimSpace(token))
+			if err != nil {
+				logger.WarnContext(ctx, "denying invalid bearer token", "methodArn", request.MethodArn, "error", err)
+				return authorizerPolicy("anonymous", "Deny", resource, nil), nil
+			}
+			subject, _ := claims.GetSubject()
+			issuer, _ := claims.GetIssuer()
+			return authorizerPolicy(subject, "Allow", resource, map[string]interface{}{
+				"authType": "jwt",
+				"sub":      subject,
+				"iss":      issuer,
+			}), nil
+		}
+	}
+
+	var apiKeys map[string]string
+	if a.apiKeys != nil {
+		apiKeys = a.apiKeys(ctx)
+	}
+	if presented := headerValue(request.Headers, "X-Api-Key"); len(apiKeys) > 0 && presented != "" {
+		id, ok := matchAPIKey(apiKeys, presented)
+		if !ok {
+			logger.WarnContext(ctx, "denying invalid API key", "methodArn", request.MethodArn)
+			return authorizerPolicy("anonymous", "Deny", resource, nil), nil
+		}
+		return authorizerPolicy("apikey:"+id, "Allow", resource, map[string]interface{}{
+			"authType": "apikey",
+			"apiKeyId": id,
+		}), nil
+	}
+
+	if authorization != "" {
+		logger.WarnContext(ctx, "denying unsupported credentials", "methodArn", request.MethodArn)
+		return authorizerPolicy("anonymous", "Deny", resource, nil), nil
+	}
+	return events.APIGatewayCustomAuthorizerResponse{}, errAuthorizerUnauthorized
+}
+
+// stageWildcardARN widens a method ARN such as
+// arn:aws:execute-api:us-east-1:123456789012:abc123/prod/GET/users/1 to
+// every method and path of its stage, .../abc123/prod/*.
+func stageWildcardARN(methodARN string) string {
+	parts := strings.SplitN(methodARN, "/", 3)
+	if len(parts) < 2 {
+		return methodARN
+	}
+	return parts[0] + "/" + parts[1] + "/*"
+}
+
+func authorizerPolicy(principalID, effect, resource string, context map[string]interface{}) events.APIGatewayCustomAuthorizerResponse {
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: principalID,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{{
+				Action:   []string{"execute-api:Invoke"},
+				Effect:   effect,
+				Resource: []string{resource},
+			}},
+		},
+		Context: context,
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// awsHTTPClient is shared by every AWS SDK client so their pooled
+// connections can be closed together at shutdown.
+var awsHTTPClient = awshttp.NewBuildableClient().Freeze()
+
+// awsClients holds the function's AWS service clients for the lifetime of
+// the container, so invocations reuse their configuration and connections.
+var awsClients = &AWSClients{}
+
+// AWSClients loads the AWS configuration once and creates each service
+// client on first use. Loading is deferred to the first call, rather than
+// done at package init, so a failure is returned to the caller, and retried
+// by the next one, instead of crashing the runtime before it can report it.
+// It is safe for concurrent use.
+//
+// The configuration comes from the standard sources: AWS_REGION sets the
+// region, and AWS_ENDPOINT_URL, or a service-specific variable such as
+// AWS_ENDPOINT_URL_DYNAMODB, points clients at a local emulator such as
+// LocalStack.
+type AWSClients struct {
+	config lazy[aws.Config]
+
+	dynamodb       lazy[*dynamodb.Client]
+	eventBridge    lazy[*eventbridge.Client]
+	s3             lazy[*s3.Client]
+	secretsManager lazy[*secretsmanager.Client]
+	sns            lazy[*sns.Client]
+	ssm            lazy[*ssm.Client]
+}
+
+// lazy is a value built on first use. A failed build is not cached, so a
+// transient error, such as a throttled credentials call, is retried by the
+// next caller instead of failing every call for the container's lifetime.
+type lazy[T any] struct {
+	mu    sync.Mutex
+	built bool
+	value T
+}
+
+// get returns the value, building it if no earlier call has succeeded.
+// build runs under a context that is not cancelled with ctx: the value
+// outlives the invocation that happened to build it, and credential
+// providers it creates may hold on to that context.
+func (l *lazy[T]) get(ctx context.Context, build func(context.Context) (T, error)) (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.built {
+		return l.value, nil
+	}
+	value, err := build(context.WithoutCancel(ctx))
+	if err != nil {
+		return value, err
+	}
+	l.value, l.built = value, true
+	return value, nil
+}
+
+// Config returns the shared AWS configuration, which uses awsHTTPClient.
+func (c *AWSClients) Config(ctx context.Context) (aws.Config, error) {
+	return c.config.get(ctx, func(ctx context.Context) (aws.Config, error) {
+		awsCfg, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(awsHTTPClient))
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("loading AWS config: %w", err)
+		}
+		return awsCfg, nil
+	})
+}
+
+// DynamoDB returns the shared DynamoDB client.
+func (c *AWSClients) DynamoDB(ctx context.Context) (*dynamodb.Client, error) {
+	return newAWSClient(ctx, c, &c.dynamodb, "dynamodb", func(cfg aws.Config) *dynamodb.Client { return dynamodb.NewFromConfig(cfg) })
+}
+
+// EventBridge returns the shared EventBridge client.
+func (c *AWSClients) EventBridge(ctx context.Context) (*eventbridge.Client, error) {
+	return newAWSClient(ctx, c, &c.eventBridge, "eventbridge", func(cfg aws.Config) *eventbridge.Client { return eventbridge.NewFromConfig(cfg) })
+}
+
+// S3 returns the shared S3 client.
+func (c *AWSClients) S3(ctx context.Context) (*s3.Client, error) {
+	return newAWSClient(ctx, c, &c.s3, "s3", func(cfg aws.Config) *s3.Client { return s3.NewFromConfig(cfg) })
+}
+
+// SecretsManager returns the shared Secrets Manager client.
+func (c *AWSClients) SecretsManager(ctx context.Context) (*secretsmanager.Client, error) {
+	return newAWSClient(ctx, c, &c.secretsManager, "secretsmanager", func(cfg aws.Config) *secretsmanager.Client { return secretsmanager.NewFromConfig(cfg) })
+}
+
+// SNS returns the shared SNS client.
+func (c *AWSClients) SNS(ctx context.Context) (*sns.Client, error) {
+	return newAWSClient(ctx, c, &c.sns, "sns", func(cfg aws.Config) *sns.Client { return sns.NewFromConfig(cfg) })
+}
+
+// SSM returns the shared Systems Manager client.
+func (c *AWSClients) SSM(ctx context.Context) (*ssm.Client, error) {
+	return newAWSClient(ctx, c, &c.ssm, "ssm", func(cfg aws.Config) *ssm.Client { return ssm.NewFromConfig(cfg) })
+}
+
+// newAWSClient builds a client from the shared configuration, timing its
+// calls under label for the request log.
+func newAWSClient[T any](ctx context.Context, c *AWSClients, l *lazy[T], label string, build func(aws.Config) T) (T, error) {
+	return l.get(ctx, func(ctx context.Context) (T, error) {
+		awsCfg, err := c.Config(ctx)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		awsCfg.APIOptions = append(slices.Clip(awsCfg.APIOptions), downstreamTimingOption(label))
+		return build(awsCfg), nil
+	})
+}
+
+// closeAWSConnections closes the idle connections of every AWS SDK client.
+func closeAWSConnections(context.Context) error {
+	if c, ok := awsHTTPClient.(*http.Client); ok {
+		c.CloseIdleConnections()
+	}
+	return nil
+}
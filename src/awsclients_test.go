@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLazyRetriesAfterError(t *testing.T) {
+	var l lazy[int]
+	calls := 0
+	build := func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("throttled")
+		}
+		return 42, nil
+	}
+
+	if _, err := l.get(context.Background(), build); err == nil {
+		t.Fatal("first get succeeded, want the build error")
+	}
+	for i := 0; i < 2; i++ {
+		v, err := l.get(context.Background(), build)
+		if err != nil || v != 42 {
+			t.Fatalf("get = %d, %v, want 42", v, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("build ran %d times, want 2: once failing, once succeeding", calls)
+	}
+}
+
+func TestLazyBuildOutlivesCallerContext(t *testing.T) {
+	var l lazy[context.Context]
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), correlationIDKey{}, "abc"))
+	built, err := l.get(ctx, func(ctx context.Context) (context.Context, error) { return ctx, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if built.Err() != nil {
+		t.Error("build context was cancelled with the caller's")
+	}
+	if CorrelationIDFromContext(built) != "abc" {
+		t.Error("build context lost the caller's values")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when MAX_BODY_BYTES is unset.
+const DefaultMaxBodyBytes = 1 << 20
+
+// BodyLimitMiddleware rejects requests whose decoded body exceeds maxBytes
+// with a 413. Base64 bodies are measured after decoding.
+func BodyLimitMiddleware(maxBytes int) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			size := len(request.Body)
+			if request.IsBase64Encoded {
+				if body, err := DecodeBody(request); err == nil {
+					size = len(body)
+				}
+			}
+
+			if size > maxBytes {
+				logger.WarnContext(ctx, "request body too large", "bodyBytes", size, "maxBytes", maxBytes)
+				return JSON(413, map[string]interface{}{
+					"error":    "Request body too large",
+					"maxBytes": maxBytes,
+				}), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	const max = 64
+	h := BodyLimitMiddleware(max)(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, nil), nil
+	})
+
+	tests := []struct {
+		name    string
+		size    int
+		encoded bool
+		want    int
+	}{
+		{"under", max - 1, false, 200},
+		{"at", max, false, 200},
+		{"over", max + 1, false, 413},
+		// The encoded text is longer than max; only the decoded size counts.
+		{"encoded at", max, true, 200},
+		{"encoded over", max + 1, true, 413},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("a", tt.size)
+			if tt.encoded {
+				body = base64.StdEncoding.EncodeToString([]byte(body))
+			}
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{Body: body, IsBase64Encoded: tt.encoded})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ResponseCacheConfig configures CacheMiddleware.
+type ResponseCacheConfig struct {
+	// TTL is how long a stored response is served; zero disables caching.
+	TTL time.Duration
+	// MaxEntries caps the number of stored responses; the least recently
+	// used is evicted beyond it.
+	MaxEntries int
+	// Methods lists the cacheable request methods.
+	Methods []string
+}
+
+// DefaultResponseCacheConfig leaves caching off, with room for 1000 GET
+// responses once a TTL is set.
+func DefaultResponseCacheConfig() ResponseCacheConfig {
+	return ResponseCacheConfig{MaxEntries: 1000, Methods: []string{"GET"}}
+}
+
+// ResponseCache holds responses in the container's memory. It is
+// best-effort: each container has its own cache, which starts empty on a
+// cold start and is lost when the container is recycled. It is safe for
+// concurrent use.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key      string
+	response Response
+	expires  time.Time
+}
+
+// NewResponseCache returns an empty cache of at most maxEntries responses,
+// each kept for ttl.
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{ttl: ttl, maxEntries: maxEntries, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *ResponseCache) get(key string) (Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return Response{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return Response{}, false
+	}
+	c.order.MoveToFront(el)
+	return copyResponse(entry.response), true
+}
+
+func (c *ResponseCache) put(key string, response Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, response: copyResponse(response), expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// copyResponse copies the headers too, since middleware outside the cache
+// edits them in place.
+func copyResponse(response Response) Response {
+	response.Headers = maps.Clone(response.Headers)
+	return response
+}
+
+// CacheMiddleware serves repeated requests from an in-memory ResponseCache.
+// Requests with a cacheable method are keyed on method, path and query
+// string, with parameters sorted so their order does not matter; a stored
+// response is returned until its TTL passes, marked X-Cache: HIT. A request
+// with Cache-Control: no-cache skips the lookup and refreshes the entry.
+//
+// Only 200 responses are stored, and not when they set a cookie or a
+// Cache-Control of no-store or private. Requests carrying credentials
+// (Authorization, X-Api-Key or Cookie) are never cached, since the key does
+// not identify the caller, and neither are conditional requests. Place it
+// innermost, so negotiation, envelopes and compression still apply to each
+// response served. A zero TTL disables it.
+func CacheMiddleware(cfg ResponseCacheConfig) Middleware {
+	if cfg.TTL <= 0 {
+		return func(next HandlerFunc) HandlerFunc { return next }
+	}
+	cache := NewResponseCache(cfg.TTL, cfg.MaxEntries)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			// Conditional requests go to the handler so ETagMiddleware can
+			// answer them with a 304.
+			if !slices.Contains(cfg.Methods, request.HTTPMethod) || hasCredentials(request) ||
+				GetHeader(request, "If-None-Match") != "" {
+				return next(ctx, request)
+			}
+
+			key := cacheKey(request)
+			if !hasToken(GetHeader(request, "Cache-Control"), "no-cache") {
+				if response, ok := cache.get(key); ok {
+					response.Headers["X-Cache"] = "HIT"
+					return response, nil
+				}
+			}
+
+			response, err := next(ctx, request)
+			if err != nil || !cacheable(response) {
+				return response, err
+			}
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			cache.put(key, response)
+			response.Headers["X-Cache"] = "MISS"
+			return response, nil
+		}
+	}
+}
+
+func hasCredentials(request events.APIGatewayProxyRequest) bool {
+	return GetHeader(request, "Authorization") != "" || GetHeader(request, "X-Api-Key") != "" || GetHeader(request, "Cookie") != ""
+}
+
+func cacheable(response Response) bool {
+	if response.StatusCode != 200 || len(response.Cookies) > 0 || headerValue(response.Headers, "Set-Cookie") != "" {
+		return false
+	}
+	cacheControl := headerValue(response.Headers, "Cache-Control")
+	return !hasToken(cacheControl, "no-store") && !hasToken(cacheControl, "private")
+}
+
+// cacheKey joins the method, path and sorted query string. Repeated
+// parameters keep their relative order, which can change their meaning.
+func cacheKey(request events.APIGatewayProxyRequest) string {
+	query := url.Values{}
+	for k, v := range request.QueryStringParameters {
+		query[k] = []string{v}
+	}
+	for k, v := range request.MultiValueQueryStringParameters {
+		query[k] = v
+	}
+	return request.HTTPMethod + " " + request.Path + "?" + query.Encode()
+}
+
+// hasToken reports whether the comma-separated header value lists token,
+// ignoring case and any =value.
+func hasToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		name, _, _ := strings.Cut(part, "=")
+		if strings.EqualFold(strings.TrimSpace(name), token) {
+			return true
+		}
+	}
+	return false
+}
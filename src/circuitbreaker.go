@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Execute, without calling the
+// dependency, while the circuit is open. ErrorResponse maps it to a 503.
+var ErrCircuitOpen = NewAPIError(http.StatusServiceUnavailable, "dependency_unavailable", "A dependency is unavailable; try again later")
+
+// CircuitBreakerConfig sets when a CircuitBreaker opens and how long it stays
+// open.
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive failures that opens the circuit.
+	Threshold int
+	// Cooldown is how long the circuit stays open before a probe call is let
+	// through.
+	Cooldown time.Duration
+}
+
+// DefaultCircuitBreakerConfig opens after five consecutive failures and
+// probes again after 30 seconds.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{Threshold: 5, Cooldown: 30 * time.Second}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker fails calls to a dependency fast once it is failing, rather
+// than letting each request wait out its own timeout. After Threshold
+// consecutive failures the circuit opens and calls are rejected with
+// ErrCircuitOpen. Once Cooldown has passed, one call is let through as a
+// probe: success closes the circuit, failure opens it for another Cooldown.
+//
+// Its state lives in the container, so each concurrent Lambda instance
+// trips independently. A nil *CircuitBreaker calls fn directly. It is safe
+// for concurrent use.
+type CircuitBreaker struct {
+	name string
+	cfg  CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a closed breaker for the dependency name, which
+// is used in its log messages.
+func NewCircuitBreaker(name string, cfg CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{name: name, cfg: cfg}
+}
+
+// Execute calls fn unless the circuit is open and returns its error. Errors
+// from a canceled or expired ctx are returned without counting as failures,
+// since they say nothing about the dependency's health.
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := fn()
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return err
+	}
+	b.record(ctx, err)
+	return err
+}
+
+// allow reports whether a call may go ahead, moving an open circuit whose
+// cooldown has passed to half-open and reserving its single probe.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cfg.Cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// release gives up a probe that ended without a verdict.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitHalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := b.state
+	if err == nil {
+		b.state = circuitClosed
+		b.failures = 0
+		b.probing = false
+	} else {
+		b.failures++
+		if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.cfg.Threshold) {
+			b.state = circuitOpen
+			b.openedAt = time.Now()
+			b.probing = false
+		}
+	}
+
+	if b.state != from {
+		logger.WarnContext(ctx, "circuit breaker state changed",
+			"dependency", b.name, "from", from.String(), "to", b.state.String(), "failures", b.failures)
+	}
+}
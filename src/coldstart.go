@@ -0,0 +1,27 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	coldStartOnce sync.Once
+	coldStart     atomic.Bool
+)
+
+// markInvocation records that an invocation has started. Only the first
+// invocation in the container is a cold start. Each event adapter calls this
+// exactly once per invocation, before anything reads IsColdStart; a second
+// call would report the invocation as warm.
+func markInvocation() {
+	first := false
+	coldStartOnce.Do(func() { first = true })
+	coldStart.Store(first)
+}
+
+// IsColdStart reports whether the current invocation is the first one this
+// container has served.
+func IsColdStart() bool {
+	return coldStart.Load()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// resetColdStart makes the next invocation the container's first again.
+func resetColdStart(t *testing.T) {
+	t.Helper()
+	coldStartOnce = sync.Once{}
+	coldStart.Store(false)
+}
+
+func TestColdStartOncePerInvocation(t *testing.T) {
+	var seen []bool
+	record := func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		seen = append(seen, IsColdStart())
+		return Response{StatusCode: 204}, nil
+	}
+	chain := Chain(record, RequestIDMiddleware)
+
+	tests := []struct {
+		name   string
+		invoke func() error
+	}{
+		{"rest", func() error {
+			_, err := restHandler(chain)(context.Background(), events.APIGatewayProxyRequest{})
+			return err
+		}},
+		{"httpapi", func() error {
+			_, err := HTTPAPIHandler(chain)(context.Background(), events.APIGatewayV2HTTPRequest{})
+			return err
+		}},
+		{"stream", func() error {
+			response, err := FunctionURLStreamHandler(StreamResponses(chain))(context.Background(), events.LambdaFunctionURLRequest{})
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(io.Discard, response.Body)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetColdStart(t)
+			seen = nil
+			for range 2 {
+				if err := tt.invoke(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(seen) != 2 || !seen[0] || seen[1] {
+				t.Errorf("IsColdStart per invocation = %v, want [true false]", seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultCompressionThreshold is the smallest body, in bytes, worth gzipping.
+const DefaultCompressionThreshold = 1024
+
+// CompressionMiddleware gzips response bodies of at least threshold bytes
+// when the client accepts gzip. Compressed bodies are base64-encoded, as API
+// Gateway requires for binary responses.
+func CompressionMiddleware(threshold int) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			response, err := next(ctx, request)
+			if err != nil || response.IsBase64Encoded || len(response.Body) < threshold {
+				return response, err
+			}
+			if !acceptsGzip(GetHeader(request, "Accept-Encoding")) {
+				return response, err
+			}
+
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, werr := zw.Write([]byte(response.Body)); werr != nil {
+				logger.ErrorContext(ctx, "compressing response", "error", werr)
+				return response, err
+			}
+			if cerr := zw.Close(); cerr != nil {
+				logger.ErrorContext(ctx, "compressing response", "error", cerr)
+				return response, err
+			}
+
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			response.Headers["Content-Encoding"] = "gzip"
+			addVary(response.Headers, "Accept-Encoding")
+			response.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+			response.IsBase64Encoded = true
+			return response, err
+		}
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding value permits gzip. An
+// explicit gzip entry decides, whatever its position; otherwise a * entry
+// does. Either is refused by a q-value of zero.
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q, ok := qValue(params)
+		if !ok {
+			continue
+		}
+		switch coding = strings.TrimSpace(coding); {
+		case strings.EqualFold(coding, "gzip"):
+			gzipQ = q
+		case coding == "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// qValue returns the weight among an Accept-Encoding entry's parameters, 1
+// when there is none. It reports false for a weight that is malformed or
+// outside 0 to 1, so the entry can be ignored.
+func qValue(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0, false
+		}
+		return q, true
+	}
+	return 1, true
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"br", false},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.000", false},
+		{"*;q=0, gzip", true},
+		{"gzip;q=0, *", false},
+		{"*, gzip;q=0", false},
+		{"br, *;q=0", false},
+		{"gzip;q=bogus", false},
+		{"gzip;level=1;q=0.8", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
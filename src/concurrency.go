@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrAtCapacity is returned when a container is already running as many
+// requests as its ConcurrencyLimiter allows. ErrorResponse maps it to a 503.
+var ErrAtCapacity = NewAPIError(http.StatusServiceUnavailable, "at_capacity", "The service is at capacity; try again later")
+
+// concurrencyLimiter is the limiter configured by main from MAX_CONCURRENCY,
+// or nil when there is no limit. The stream entry point holds a slot for
+// each response it streams.
+var concurrencyLimiter *ConcurrencyLimiter
+
+// ConcurrencyLimiter caps the work in flight within one container, turning
+// away the excess instead of queueing it, to protect memory-heavy
+// downstreams. Lambda hands a container one event at a time, so plain
+// request handlers never contend; it matters where work outlives or runs
+// beside the invocation that started it, as with streamed responses and
+// background goroutines. A nil limiter admits everything.
+type ConcurrencyLimiter struct {
+	slots      chan struct{}
+	retryAfter RetryAfterConfig
+}
+
+// NewConcurrencyLimiter returns a limiter admitting limit requests at once,
+// whose rejections suggest retrying after retryAfter. It returns nil when
+// limit is zero or less.
+func NewConcurrencyLimiter(limit int, retryAfter RetryAfterConfig) *ConcurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &ConcurrencyLimiter{slots: make(chan struct{}, limit), retryAfter: retryAfter}
+}
+
+// TryAcquire takes a slot without waiting. When ok, release must be called
+// once the work is done.
+func (l *ConcurrencyLimiter) TryAcquire() (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// Stream returns h holding a slot for as long as it streams. At capacity it
+// answers, before writing anything, with a 503 carrying Retry-After.
+func (l *ConcurrencyLimiter) Stream(h StreamHandler) StreamHandler {
+	if l == nil {
+		return h
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+		release, ok := l.TryAcquire()
+		if !ok {
+			logger.WarnContext(ctx, "rejecting request at capacity", "path", request.Path, "limit", cap(l.slots))
+			if sw, ok := w.(*StreamWriter); ok {
+				sw.SetHeader("Retry-After", retryAfterValue(l.retryAfter.delay()))
+			}
+			return ErrAtCapacity
+		}
+		defer release()
+		return h(ctx, request, w)
+	}
+}
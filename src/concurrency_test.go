@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestConcurrencyLimiterStream(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, RetryAfterConfig{Base: 2 * time.Second})
+	release := make(chan struct{})
+	h := FunctionURLStreamHandler(limiter.Stream(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+		if _, err := io.WriteString(w, "started"); err != nil {
+			return err
+		}
+		<-release
+		return nil
+	}))
+
+	// The first stream holds the only slot until its body is drained.
+	first, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+	if err != nil || first.StatusCode != 200 {
+		t.Fatalf("first stream = %v, %v, want a 200", first, err)
+	}
+
+	second, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.StatusCode != 503 || second.Headers["Retry-After"] == "" {
+		t.Errorf("second stream = %d %v, want a 503 with Retry-After", second.StatusCode, second.Headers)
+	}
+
+	close(release)
+	if _, err := io.ReadAll(first.Body); err != nil {
+		t.Fatal(err)
+	}
+	// The slot is released as the handler returns, before the body closes.
+	third, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+	if err != nil || third.StatusCode != 200 {
+		t.Fatalf("stream after release = %d, %v, want a 200", third.StatusCode, err)
+	}
+	io.ReadAll(third.Body)
+}
+
+func TestNilConcurrencyLimiter(t *testing.T) {
+	var limiter *ConcurrencyLimiter
+	if l := NewConcurrencyLimiter(0, RetryAfterConfig{}); l != nil {
+		t.Error("NewConcurrencyLimiter(0) is not nil")
+	}
+	release, ok := limiter.TryAcquire()
+	if !ok {
+		t.Fatal("nil limiter refused")
+	}
+	release()
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every setting the function reads from its environment.
+type Config struct {
+	LogLevel slog.Level
+	Logging  LoggingConfig
+	CORS     CORSConfig
+
+	// CorrelationHeader carries the correlation ID in and out of requests.
+	CorrelationHeader string
+
+	// DefaultLocale is the language of error messages when the client's
+	// Accept-Language matches no message catalog.
+	DefaultLocale string
+
+	// Envelope wraps JSON bodies in the canonical data/error/meta envelope.
+	Envelope EnvelopeConfig
+
+	MaxBodyBytes         int
+	JSONLimits           JSONLimits
+	MaxDecompressedBytes int
+	CompressionThreshold int
+	MetricsNamespace     string
+
+	IdempotencyTable string
+	IdempotencyTTL   time.Duration
+	// IdempotencyLockTimeout bounds how long an in-progress request holds
+	// its Idempotency-Key; keep it near the function timeout.
+	IdempotencyLockTimeout time.Duration
+	// IdempotencyFingerprintFallback keys writes sent without an
+	// Idempotency-Key by their request fingerprint.
+	IdempotencyFingerprintFallback bool
+
+	// Fingerprint chooses what RequestFingerprint hashes.
+	Fingerprint FingerprintConfig
+
+	// JWT enables bearer-token authentication when JWKSURL is set.
+	JWT JWTConfig
+	// APIKeys maps key identifiers to API keys for service-to-service routes.
+	APIKeys map[string]string
+
+	// RateLimitRate is the sustained requests per second allowed per caller;
+	// zero disables rate limiting.
+	RateLimitRate    float64
+	RateLimitBurst   int
+	RateLimitBackend string
+	RateLimitTable   string
+
+	// MaxConcurrency caps the streamed responses and background work in
+	// flight per container; zero means no limit.
+	MaxConcurrency int
+
+	// RetryAfter is the retry hint sent with 429 and 503 responses.
+	RetryAfter RetryAfterConfig
+
+	// Cache stores GET responses in the container's memory when its TTL is
+	// set.
+	Cache ResponseCacheConfig
+
+	// FanOutDeadline is the soft deadline FanOut uses by default.
+	FanOutDeadline time.Duration
+
+	// PresignTTL is how long presigned S3 URLs stay valid by default.
+	PresignTTL time.Duration
+
+	// HTTPClient sets the timeouts and retries of calls to external HTTP
+	// APIs.
+	HTTPClient HTTPClientConfig
+
+	// CircuitBreaker sets when calls to a failing dependency start failing
+	// fast.
+	CircuitBreaker CircuitBreakerConfig
+
+	// Alerts reports server errors and panics to SNS or EventBridge when a
+	// target is set.
+	Alerts AlertConfig
+
+	// SecretID names a Secrets Manager secret loaded at startup; empty
+	// disables secret loading.
+	SecretID               string
+	SecretsRefreshInterval time.Duration
+
+	// Warmup identifies scheduled warmer events, answered without running
+	// the handler.
+	Warmup WarmupConfig
+
+	// KinesisSkipFailed makes KinesisHandler log and skip records that fail
+	// rather than failing, and retrying, the whole batch.
+	KinesisSkipFailed bool
+
+	// SQSDedupWindow is how long processed SQS messages are remembered, so
+	// redeliveries are skipped; zero disables deduplication.
+	SQSDedupWindow    time.Duration
+	SQSDedupBackend   string
+	SQSDedupTable     string
+	SQSDedupAttribute string
+
+	// FeatureFlags holds each flag's rollout percentage. AllowFlagOverrides
+	// lets requests force flags with the X-Feature-Flags header, and must
+	// stay off in production.
+	FeatureFlags       FeatureFlags
+	AllowFlagOverrides bool
+
+	// ShutdownTimeout bounds the cleanup run when Lambda sends SIGTERM.
+	ShutdownTimeout time.Duration
+
+	// Feature toggles.
+	EnableCompression bool
+	EnableTracing     bool
+	EnableWarmup      bool
+	// EnableResponseSizeHeader adds X-Response-Size to responses, for
+	// debugging payload sizes.
+	EnableResponseSizeHeader bool
+}
+
+// DefaultConfig returns the settings used for any variable left unset.
+func DefaultConfig() Config {
+	return Config{
+		LogLevel:               slog.LevelInfo,
+		Logging:                DefaultLoggingConfig(),
+		CORS:                   DefaultCORSConfig(),
+		CorrelationHeader:      DefaultCorrelationHeader,
+		DefaultLocale:          DefaultLocale,
+		Envelope:               DefaultEnvelopeConfig(),
+		MaxBodyBytes:           DefaultMaxBodyBytes,
+		JSONLimits:             DefaultJSONLimits(),
+		MaxDecompressedBytes:   DefaultMaxDecompressedBytes,
+		CompressionThreshold:   DefaultCompressionThreshold,
+		RateLimitBurst:         20,
+		RateLimitBackend:       "memory",
+		SQSDedupBackend:        "memory",
+		RetryAfter:             DefaultRetryAfterConfig(),
+		CircuitBreaker:         DefaultCircuitBreakerConfig(),
+		HTTPClient:             DefaultHTTPClientConfig(),
+		Cache:                  DefaultResponseCacheConfig(),
+		Alerts:                 DefaultAlertConfig(),
+		PresignTTL:             DefaultPresignTTL,
+		FanOutDeadline:         DefaultFanOutDeadline,
+		MetricsNamespace:       DefaultMetricsNamespace,
+		IdempotencyTTL:         DefaultIdempotencyTTL,
+		IdempotencyLockTimeout: DefaultIdempotencyLockTimeout,
+		Fingerprint:            DefaultFingerprintConfig(),
+		SecretsRefreshInterval: DefaultSecretsRefreshInterval,
+		Warmup:                 DefaultWarmupConfig(),
+		ShutdownTimeout:        DefaultShutdownTimeout,
+		EnableCompression:      true,
+		EnableTracing:          true,
+		EnableWarmup:           true,
+	}
+}
+
+// LoadConfig reads Config from the environment, starting from DefaultConfig.
+// When CONFIG_SSM_PATH is set, parameters under that Parameter Store path
+// supply any variable the environment leaves unset. Every malformed variable
+// is reported, so a misconfigured deployment fails at startup with the full
+// list rather than one error per redeploy.
+func LoadConfig(ctx context.Context) (Config, error) {
+	params, err := loadParameters(ctx, strings.TrimSpace(os.Getenv("CONFIG_SSM_PATH")))
+	if err != nil {
+		return Config{}, fmt.Errorf("loading configuration parameters: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	env := envReader{params: params}
+
+	if v := env.lookup("LOG_LEVEL"); v != "" {
+		level, err := parseLogLevel(v)
+		env.check("LOG_LEVEL", err)
+		cfg.LogLevel = level
+	}
+
+	// Configured names add to the defaults, so a deployment cannot
+	// accidentally start logging credentials.
+	cfg.Logging.RedactHeaders = append(cfg.Logging.RedactHeaders, env.list("LOG_REDACT_HEADERS", nil)...)
+	cfg.Logging.RedactFields = append(cfg.Logging.RedactFields, env.list("LOG_REDACT_FIELDS", nil)...)
+	cfg.Logging.BodyLogSampleRate = env.number("BODY_LOG_SAMPLE_RATE", cfg.Logging.BodyLogSampleRate)
+	if cfg.Logging.BodyLogSampleRate > 1 {
+		env.check("BODY_LOG_SAMPLE_RATE", fmt.Errorf("%g must be between 0 and 1", cfg.Logging.BodyLogSampleRate))
+	}
+
+	cfg.CORS.AllowedOrigins = env.list("CORS_ALLOWED_ORIGINS", cfg.CORS.AllowedOrigins)
+	cfg.CORS.AllowedMethods = env.list("CORS_ALLOWED_METHODS", cfg.CORS.AllowedMethods)
+	cfg.CORS.AllowedHeaders = env.list("CORS_ALLOWED_HEADERS", cfg.CORS.AllowedHeaders)
+	cfg.CORS.MaxAge = env.integer("CORS_MAX_AGE", cfg.CORS.MaxAge, 0)
+
+	if v := env.lookup("CORRELATION_ID_HEADER"); v != "" {
+		cfg.CorrelationHeader = v
+	}
+
+	if v := env.lookup("DEFAULT_LOCALE"); v != "" {
+		cfg.DefaultLocale = v
+	}
+
+	cfg.Envelope.Enabled = env.boolean("ENABLE_ENVELOPE", cfg.Envelope.Enabled)
+	cfg.Envelope.RawPaths = env.list("ENVELOPE_RAW_PATHS", cfg.Envelope.RawPaths)
+
+	cfg.MaxBodyBytes = env.integer("MAX_BODY_BYTES", cfg.MaxBodyBytes, 1)
+	cfg.JSONLimits.MaxDepth = env.integer("JSON_MAX_DEPTH", cfg.JSONLimits.MaxDepth, 1)
+	cfg.JSONLimits.MaxTokens = env.integer("JSON_MAX_TOKENS", cfg.JSONLimits.MaxTokens, 1)
+	cfg.MaxDecompressedBytes = env.integer("MAX_DECOMPRESSED_BYTES", cfg.MaxDecompressedBytes, 1)
+	cfg.CompressionThreshold = env.integer("COMPRESSION_THRESHOLD", cfg.CompressionThreshold, 0)
+	if v := env.lookup("METRICS_NAMESPACE"); v != "" {
+		cfg.MetricsNamespace = v
+	}
+
+	cfg.IdempotencyTable = env.lookup("IDEMPOTENCY_TABLE")
+	cfg.IdempotencyTTL = env.duration("IDEMPOTENCY_TTL", cfg.IdempotencyTTL)
+	cfg.IdempotencyLockTimeout = env.duration("IDEMPOTENCY_LOCK_TIMEOUT", cfg.IdempotencyLockTimeout)
+	cfg.IdempotencyFingerprintFallback = env.boolean("IDEMPOTENCY_FINGERPRINT_FALLBACK", cfg.IdempotencyFingerprintFallback)
+
+	cfg.Fingerprint.Headers = env.list("FINGERPRINT_HEADERS", cfg.Fingerprint.Headers)
+	cfg.Fingerprint.IncludeBody = env.boolean("FINGERPRINT_INCLUDE_BODY", cfg.Fingerprint.IncludeBody)
+
+	cfg.JWT.JWKSURL = env.lookup("JWT_JWKS_URL")
+	cfg.JWT.Issuer = env.lookup("JWT_ISSUER")
+	cfg.JWT.Audience = env.lookup("JWT_AUDIENCE")
+	if cfg.JWT.JWKSURL != "" && cfg.JWT.Audience == "" {
+		env.check("JWT_AUDIENCE", errors.New("required when JWT_JWKS_URL is set"))
+	}
+
+	cfg.EnableCompression = env.boolean("ENABLE_COMPRESSION", cfg.EnableCompression)
+	cfg.EnableTracing = env.boolean("ENABLE_TRACING", cfg.EnableTracing)
+	cfg.EnableWarmup = env.boolean("ENABLE_WARMUP", cfg.EnableWarmup)
+	cfg.EnableResponseSizeHeader = env.boolean("ENABLE_RESPONSE_SIZE_HEADER", cfg.EnableResponseSizeHeader)
+
+	cfg.APIKeys = env.keyValues("API_KEYS")
+
+	cfg.RateLimitRate = env.number("RATE_LIMIT_RPS", cfg.RateLimitRate)
+	cfg.RateLimitBurst = env.integer("RATE_LIMIT_BURST", cfg.RateLimitBurst, 1)
+	if v := env.lookup("RATE_LIMIT_BACKEND"); v != "" {
+		cfg.RateLimitBackend = strings.ToLower(v)
+	}
+	cfg.RateLimitTable = env.lookup("RATE_LIMIT_TABLE")
+	switch {
+	case cfg.RateLimitBackend != "memory" && cfg.RateLimitBackend != "dynamodb":
+		env.check("RATE_LIMIT_BACKEND", fmt.Errorf("%q must be memory or dynamodb", cfg.RateLimitBackend))
+	case cfg.RateLimitBackend == "dynamodb" && cfg.RateLimitTable == "":
+		env.check("RATE_LIMIT_TABLE", errors.New("required when RATE_LIMIT_BACKEND is dynamodb"))
+	}
+
+	cfg.MaxConcurrency = env.integer("MAX_CONCURRENCY", cfg.MaxConcurrency, 0)
+
+	cfg.RetryAfter.Base = env.duration("RETRY_AFTER_BASE", cfg.RetryAfter.Base)
+	cfg.RetryAfter.Jitter = env.duration("RETRY_AFTER_JITTER", cfg.RetryAfter.Jitter)
+
+	cfg.Cache.TTL = env.duration("CACHE_TTL", cfg.Cache.TTL)
+	cfg.Cache.MaxEntries = env.integer("CACHE_MAX_ENTRIES", cfg.Cache.MaxEntries, 1)
+	cfg.Cache.Methods = env.list("CACHE_METHODS", cfg.Cache.Methods)
+
+	cfg.PresignTTL = env.duration("PRESIGN_TTL", cfg.PresignTTL)
+	if cfg.PresignTTL > maxPresignTTL {
+		env.check("PRESIGN_TTL", fmt.Errorf("%s must be at most %s", cfg.PresignTTL, maxPresignTTL))
+	}
+
+	cfg.FanOutDeadline = env.duration("FAN_OUT_SOFT_DEADLINE", cfg.FanOutDeadline)
+
+	cfg.CircuitBreaker.Threshold = env.integer("CIRCUIT_BREAKER_THRESHOLD", cfg.CircuitBreaker.Threshold, 1)
+	cfg.CircuitBreaker.Cooldown = env.duration("CIRCUIT_BREAKER_COOLDOWN", cfg.CircuitBreaker.Cooldown)
+
+	cfg.Alerts.SNSTopicARN = env.lookup("ALERT_SNS_TOPIC_ARN")
+	cfg.Alerts.EventBusName = env.lookup("ALERT_EVENT_BUS")
+	if v := env.lookup("ALERT_EVENT_SOURCE"); v != "" {
+		cfg.Alerts.Source = v
+	}
+	cfg.Alerts.MinInterval = env.duration("ALERT_MIN_INTERVAL", cfg.Alerts.MinInterval)
+
+	cfg.HTTPClient.Timeout = env.duration("HTTP_CLIENT_TIMEOUT", cfg.HTTPClient.Timeout)
+	cfg.HTTPClient.MaxRetries = env.integer("HTTP_CLIENT_MAX_RETRIES", cfg.HTTPClient.MaxRetries, 0)
+	cfg.HTTPClient.CorrelationHeader = cfg.CorrelationHeader
+
+	cfg.SecretID = env.lookup("SECRET_ID")
+	cfg.SecretsRefreshInterval = env.duration("SECRETS_REFRESH_INTERVAL", cfg.SecretsRefreshInterval)
+
+	cfg.KinesisSkipFailed = env.boolean("KINESIS_SKIP_FAILED_RECORDS", cfg.KinesisSkipFailed)
+
+	cfg.SQSDedupWindow = env.duration("SQS_DEDUP_WINDOW", cfg.SQSDedupWindow)
+	if v := env.lookup("SQS_DEDUP_BACKEND"); v != "" {
+		cfg.SQSDedupBackend = strings.ToLower(v)
+	}
+	cfg.SQSDedupTable = env.lookup("SQS_DEDUP_TABLE")
+	cfg.SQSDedupAttribute = env.lookup("SQS_DEDUP_ATTRIBUTE")
+	switch {
+	case cfg.SQSDedupBackend != "memory" && cfg.SQSDedupBackend != "dynamodb":
+		env.check("SQS_DEDUP_BACKEND", fmt.Errorf("%q must be memory or dynamodb", cfg.SQSDedupBackend))
+	case cfg.SQSDedupBackend == "dynamodb" && cfg.SQSDedupTable == "":
+		env.check("SQS_DEDUP_TABLE", errors.New("required when SQS_DEDUP_BACKEND is dynamodb"))
+	}
+
+	for name, raw := range env.keyValues("FEATURE_FLAGS") {
+		pct, err := parseFlagValue(raw)
+		env.check("FEATURE_FLAGS", err)
+		if cfg.FeatureFlags == nil {
+			cfg.FeatureFlags = FeatureFlags{}
+		}
+		cfg.FeatureFlags[name] = pct
+	}
+	cfg.AllowFlagOverrides = env.boolean("ALLOW_FLAG_OVERRIDES", cfg.AllowFlagOverrides)
+
+	cfg.ShutdownTimeout = env.duration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	if cfg.ShutdownTimeout >= 500*time.Millisecond {
+		env.check("SHUTDOWN_TIMEOUT", fmt.Errorf("%s must be below 500ms, when Lambda sends SIGKILL", cfg.ShutdownTimeout))
+	}
+
+	if v := env.lookup("WARMUP_FIELD"); v != "" {
+		cfg.Warmup.Field = v
+	}
+	if v := env.lookup("WARMUP_VALUE"); v != "" {
+		cfg.Warmup.Value = v
+	}
+
+	if len(env.errs) > 0 {
+		return Config{}, fmt.Errorf("invalid configuration: %w", errors.Join(env.errs...))
+	}
+	return cfg, nil
+}
+
+// envReader parses environment variables, collecting an error for each
+// malformed value instead of stopping at the first. Variables that are unset
+// or empty fall back to params.
+type envReader struct {
+	params map[string]string
+	errs   []error
+}
+
+func (e *envReader) check(name string, err error) {
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %w", name, err))
+	}
+}
+
+func (e *envReader) lookup(name string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return strings.TrimSpace(e.params[name])
+}
+
+func (e *envReader) list(name string, fallback []string) []string {
+	if v := splitList(e.lookup(name)); len(v) > 0 {
+		return v
+	}
+	return fallback
+}
+
+// keyValues parses a comma-separated list of name:value pairs.
+func (e *envReader) keyValues(name string) map[string]string {
+	items := splitList(e.lookup(name))
+	if len(items) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(items))
+	for i, item := range items {
+		k, v, ok := strings.Cut(item, ":")
+		if !ok || strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
+			// Never echo the entry: it may contain a secret.
+			e.check(name, fmt.Errorf("entry %d must have the form name:value", i+1))
+			continue
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+func (e *envReader) integer(name string, fallback, minimum int) int {
+	raw := e.lookup(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		e.check(name, fmt.Errorf("%q is not an integer", raw))
+		return fallback
+	}
+	if v < minimum {
+		e.check(name, fmt.Errorf("%d must be at least %d", v, minimum))
+		return fallback
+	}
+	return v
+}
+
+func (e *envReader) number(name string, fallback float64) float64 {
+	raw := e.lookup(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		e.check(name, fmt.Errorf("%q is not a non-negative number", raw))
+		return fallback
+	}
+	return v
+}
+
+func (e *envReader) duration(name string, fallback time.Duration) time.Duration {
+	raw := e.lookup(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil || v <= 0 {
+		e.check(name, fmt.Errorf("%q is not a positive duration such as 30s or 24h", raw))
+		return fallback
+	}
+	return v
+}
+
+func (e *envReader) boolean(name string, fallback bool) bool {
+	raw := e.lookup(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		e.check(name, fmt.Errorf("%q is not a boolean", raw))
+		return fallback
+	}
+	return v
+}
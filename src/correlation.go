@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	mathrand "math/rand/v2"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultCorrelationHeader carries the correlation ID when
+// CORRELATION_ID_HEADER is unset.
+const DefaultCorrelationHeader = "X-Correlation-ID"
+
+// maxCorrelationIDLength bounds an ID accepted from a client, since it is
+// copied into every log line.
+const maxCorrelationIDLength = 128
+
+type correlationIDKey struct{}
+
+// CorrelationIDMiddleware propagates a correlation ID across services. It
+// takes the ID from the named request header, matched case-insensitively,
+// or generates a UUID when the header is absent or unusable, stores it in
+// the context for logging and for forwarding on downstream calls, and echoes
+// it in the same response header.
+func CorrelationIDMiddleware(headerName string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			id := GetHeader(request, headerName)
+			if !validCorrelationID(id) {
+				id = newUUID()
+			}
+
+			response, err := next(context.WithValue(ctx, correlationIDKey{}, id), request)
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			takeHeader(response.Headers, headerName)
+			response.Headers[headerName] = id
+			return response, err
+		}
+	}
+}
+
+// CorrelationIDFromContext returns the correlation ID for the current
+// request, or "" outside CorrelationIDMiddleware. Forward it in the same
+// header on calls to other services.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// validCorrelationID accepts IDs of printable ASCII, so a client cannot
+// forge log lines or response headers with control characters.
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	// Before Go 1.24 crypto/rand.Read can fail if the system's source is
+	// unavailable. A correlation ID needs to be unique, not secret, so fall
+	// back to the runtime's own generator rather than sharing a zero ID.
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.Warn("reading crypto/rand for a correlation ID", "error", err)
+		binary.LittleEndian.PutUint64(b[:8], mathrand.Uint64())
+		binary.LittleEndian.PutUint64(b[8:], mathrand.Uint64())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
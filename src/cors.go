@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CORSConfig controls which cross-origin callers receive CORS headers.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is how long, in seconds, browsers may cache a preflight result.
+	MaxAge int
+}
+
+// DefaultCORSConfig allows every origin with the methods and headers the
+// API has always advertised.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "X-Amz-Date", "Authorization", "X-Api-Key", "X-Amz-Security-Token"},
+		MaxAge:         600,
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or
+// false when the origin is not whitelisted.
+func (c CORSConfig) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return "*", true
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// headers returns the CORS response headers for a request from origin.
+func (c CORSConfig) headers(origin string) map[string]string {
+	allowOrigin, ok := c.allowOrigin(origin)
+	if !ok {
+		return nil
+	}
+
+	headers := map[string]string{
+		"Access-Control-Allow-Origin":  allowOrigin,
+		"Access-Control-Allow-Headers": strings.Join(c.AllowedHeaders, ","),
+		"Access-Control-Allow-Methods": strings.Join(c.AllowedMethods, ","),
+	}
+	return headers
+}
+
+// CORSMiddleware adds CORS headers to responses for whitelisted origins and
+// omits them entirely otherwise. Preflight responses that carry an Allow
+// header advertise exactly those methods, plus Access-Control-Max-Age.
+func CORSMiddleware(cfg CORSConfig) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			response, err := next(ctx, request)
+
+			headers := cfg.headers(GetHeader(request, "Origin"))
+			if len(headers) == 0 {
+				return response, err
+			}
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			for k, v := range headers {
+				response.Headers[k] = v
+			}
+			if headers["Access-Control-Allow-Origin"] != "*" {
+				addVary(response.Headers, "Origin")
+			}
+			if strings.EqualFold(request.HTTPMethod, "OPTIONS") {
+				if allow := response.Headers["Allow"]; allow != "" {
+					response.Headers["Access-Control-Allow-Methods"] = strings.ReplaceAll(allow, " ", "")
+				}
+				response.Headers["Access-Control-Max-Age"] = strconv.Itoa(cfg.MaxAge)
+			}
+			return response, err
+		}
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
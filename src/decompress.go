@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultMaxDecompressedBytes is the decompressed request body limit used
+// when MAX_DECOMPRESSED_BYTES is unset.
+const DefaultMaxDecompressedBytes = 10 << 20
+
+var errDecompressedTooLarge = errors.New("decompressed body too large")
+
+// DecompressionMiddleware inflates request bodies sent with
+// Content-Encoding: gzip, so DecodeBody, BindJSON and ParseForm see the
+// original bytes. The Content-Encoding and Content-Length headers are dropped
+// from the request handed on. A body that inflates beyond maxBytes is
+// rejected with a 413, guarding against decompression bombs, and one that is
+// not valid gzip with a 400. Other encodings pass through untouched.
+func DecompressionMiddleware(maxBytes int) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			encoding := strings.ToLower(strings.TrimSpace(GetHeader(request, "Content-Encoding")))
+			if encoding != "gzip" && encoding != "x-gzip" {
+				return next(ctx, request)
+			}
+
+			body, err := DecodeBody(request)
+			if err != nil {
+				return Error(400, "Invalid base64 request body"), nil
+			}
+			data, err := gunzip(body, maxBytes)
+			if errors.Is(err, errDecompressedTooLarge) {
+				logger.WarnContext(ctx, "decompressed request body too large", "bodyBytes", len(body), "maxBytes", maxBytes)
+				return JSON(413, map[string]interface{}{
+					"error":    "Decompressed request body too large",
+					"maxBytes": maxBytes,
+				}), nil
+			}
+			if err != nil {
+				logger.WarnContext(ctx, "decompressing request body", "error", err)
+				return Error(400, "Invalid gzip request body"), nil
+			}
+
+			request.Headers = withoutHeaders(request.Headers, "Content-Encoding", "Content-Length")
+			request.MultiValueHeaders = withoutHeaders(request.MultiValueHeaders, "Content-Encoding", "Content-Length")
+			if utf8.Valid(data) {
+				request.Body, request.IsBase64Encoded = string(data), false
+			} else {
+				request.Body, request.IsBase64Encoded = base64.StdEncoding.EncodeToString(data), true
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// gunzip inflates body, reading at most one byte past maxBytes so an
+// oversized payload is detected without inflating all of it.
+func gunzip(body []byte, maxBytes int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, int64(maxBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBytes {
+		return nil, errDecompressedTooLarge
+	}
+	return data, nil
+}
+
+// withoutHeaders returns a copy of headers without the named ones, matched
+// case-insensitively, leaving the caller's map untouched.
+func withoutHeaders[V any](headers map[string]V, names ...string) map[string]V {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]V, len(headers))
+	for k, v := range headers {
+		drop := false
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+type downstreamKey struct{}
+
+// downstreamTimings accumulates the time one invocation spent in downstream
+// calls, by label. Calls may run concurrently, as in a fan-out, hence the
+// lock.
+type downstreamTimings struct {
+	mu     sync.Mutex
+	totals map[string]time.Duration
+}
+
+// withDownstreamTimings returns ctx carrying a fresh timing registry.
+// LoggingMiddleware installs one per request, so each invocation's totals
+// are its own.
+func withDownstreamTimings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, downstreamKey{}, &downstreamTimings{totals: map[string]time.Duration{}})
+}
+
+// RecordDownstream adds elapsed to the invocation's total for label, such as
+// "dynamodb" or "payments-api". It does nothing outside a request, where
+// there is no registry.
+func RecordDownstream(ctx context.Context, label string, elapsed time.Duration) {
+	t, ok := ctx.Value(downstreamKey{}).(*downstreamTimings)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	t.totals[label] += elapsed
+	t.mu.Unlock()
+}
+
+// TimeDownstream runs fn and records its duration under label. AWS SDK
+// clients from awsClients are timed already; use it for other calls, such as
+// to an HTTP API.
+func TimeDownstream(ctx context.Context, label string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	RecordDownstream(ctx, label, time.Since(start))
+	return err
+}
+
+// downstreamMillis returns the invocation's totals in milliseconds, or nil
+// when nothing was recorded.
+func downstreamMillis(ctx context.Context) map[string]int64 {
+	t, ok := ctx.Value(downstreamKey{}).(*downstreamTimings)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.totals) == 0 {
+		return nil
+	}
+	millis := make(map[string]int64, len(t.totals))
+	for label, d := range t.totals {
+		millis[label] = d.Milliseconds()
+	}
+	return millis
+}
+
+// downstreamTimingOption times every operation of an AWS SDK client under
+// label. It runs first in the initialize step, so the time includes retries
+// and backoff.
+func downstreamTimingOption(label string) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("DownstreamTiming",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				RecordDownstream(ctx, label, time.Since(start))
+				return out, metadata, err
+			}), middleware.Before)
+	}
+}
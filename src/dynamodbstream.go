@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Stream event names, as reported in DynamoDBEventRecord.EventName.
+const (
+	StreamInsert = "INSERT"
+	StreamModify = "MODIFY"
+	StreamRemove = "REMOVE"
+)
+
+// StreamChange is one decoded DynamoDB stream record. NewImage is nil for
+// REMOVE and OldImage is nil for INSERT, or whenever the stream view type
+// does not include that image.
+type StreamChange[T any] struct {
+	EventName string
+	NewImage  *T
+	OldImage  *T
+	Record    events.DynamoDBEventRecord
+}
+
+// StreamFunc handles one change of a given event type.
+type StreamFunc[T any] func(ctx context.Context, change StreamChange[T]) error
+
+// DynamoDBStreamHandler decodes DynamoDB stream records into T and dispatches
+// them to the callback registered for their event name. Records with no
+// registered callback are ignored.
+type DynamoDBStreamHandler[T any] struct {
+	callbacks map[string]StreamFunc[T]
+
+	// SkipInvalid logs and skips records whose images fail to unmarshal into
+	// T. When false, such a record fails the batch like a callback error.
+	SkipInvalid bool
+}
+
+// NewDynamoDBStreamHandler returns a handler with no callbacks registered.
+func NewDynamoDBStreamHandler[T any]() *DynamoDBStreamHandler[T] {
+	return &DynamoDBStreamHandler[T]{callbacks: map[string]StreamFunc[T]{}}
+}
+
+// OnInsert registers fn for INSERT records.
+func (h *DynamoDBStreamHandler[T]) OnInsert(fn StreamFunc[T]) { h.callbacks[StreamInsert] = fn }
+
+// OnModify registers fn for MODIFY records.
+func (h *DynamoDBStreamHandler[T]) OnModify(fn StreamFunc[T]) { h.callbacks[StreamModify] = fn }
+
+// OnRemove registers fn for REMOVE records.
+func (h *DynamoDBStreamHandler[T]) OnRemove(fn StreamFunc[T]) { h.callbacks[StreamRemove] = fn }
+
+// Handle processes event's records in order. Stream shards are ordered, so
+// it stops at the first failure, a panicking callback included, and reports that record's sequence number in
+// BatchItemFailures; Lambda then retries from it. This needs
+// ReportBatchItemFailures enabled on the event source mapping.
+func (h *DynamoDBStreamHandler[T]) Handle(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	markInvocation()
+	ctx = withRequestIDs(ctx, "")
+
+	var response events.DynamoDBEventResponse
+	for i, record := range event.Records {
+		if err := h.handleRecord(ctx, record); err != nil {
+			logger.ErrorContext(ctx, "processing DynamoDB stream record",
+				"eventId", record.EventID,
+				"eventName", record.EventName,
+				"error", err,
+			)
+			response.BatchItemFailures = []events.DynamoDBBatchItemFailure{
+				{ItemIdentifier: record.Change.SequenceNumber},
+			}
+			logger.InfoContext(ctx, "dynamodb stream batch", "records", len(event.Records), "processed", i)
+			return response, nil
+		}
+	}
+
+	logger.InfoContext(ctx, "dynamodb stream batch", "records", len(event.Records), "processed", len(event.Records))
+	return response, nil
+}
+
+func (h *DynamoDBStreamHandler[T]) handleRecord(ctx context.Context, record events.DynamoDBEventRecord) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorContext(ctx, "panic processing DynamoDB stream record",
+				"eventId", record.EventID,
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()),
+			)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn, ok := h.callbacks[record.EventName]
+	if !ok {
+		return nil
+	}
+
+	change := StreamChange[T]{EventName: record.EventName, Record: record}
+	if change.NewImage, err = unmarshalImage[T](record.Change.NewImage); err == nil {
+		change.OldImage, err = unmarshalImage[T](record.Change.OldImage)
+	}
+	if err != nil {
+		if h.SkipInvalid {
+			logger.WarnContext(ctx, "skipping undecodable DynamoDB stream record",
+				"eventId", record.EventID,
+				"error", err,
+			)
+			return nil
+		}
+		return err
+	}
+	return fn(ctx, change)
+}
+
+func unmarshalImage[T any](image map[string]events.DynamoDBAttributeValue) (*T, error) {
+	if len(image) == 0 {
+		return nil, nil
+	}
+	item, err := streamAttributeMap(image)
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := attributevalue.UnmarshalMap(item, &v); err != nil {
+		return nil, fmt.Errorf("unmarshaling stream image: %w", err)
+	}
+	return &v, nil
+}
+
+// streamAttributeMap converts attribute values from the Lambda event types
+// to the SDK types that attributevalue works with.
+func streamAttributeMap(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(image))
+	for name, av := range image {
+		v, err := streamAttribute(av)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		item[name] = v
+	}
+	return item, nil
+}
+
+func streamAttribute(av events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch av.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: av.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: av.Number()}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: av.Binary()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: av.Boolean()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: av.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: av.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: av.BinarySet()}, nil
+	case events.DataTypeList:
+		list := make([]types.AttributeValue, 0, len(av.List()))
+		for _, elem := range av.List() {
+			v, err := streamAttribute(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case events.DataTypeMap:
+		m, err := streamAttributeMap(av.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: m}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute type %d", av.DataType())
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// sampleStreamEvent has the shape DynamoDB Streams delivers to Lambda with
+// the NEW_AND_OLD_IMAGES view type.
+const sampleStreamEvent = `{"Records": [
+	{
+		"eventID": "1",
+		"eventName": "INSERT",
+		"eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"Keys": {"id": {"S": "order-1"}},
+			"NewImage": {
+				"id": {"S": "order-1"},
+				"total": {"N": "42.5"},
+				"paid": {"BOOL": false},
+				"tags": {"SS": ["gift"]},
+				"lines": {"L": [{"M": {"sku": {"S": "A1"}, "qty": {"N": "2"}}}]}
+			},
+			"SequenceNumber": "100",
+			"StreamViewType": "NEW_AND_OLD_IMAGES"
+		}
+	},
+	{
+		"eventID": "2",
+		"eventName": "MODIFY",
+		"eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"Keys": {"id": {"S": "order-1"}},
+			"OldImage": {"id": {"S": "order-1"}, "total": {"N": "42.5"}, "paid": {"BOOL": false}},
+			"NewImage": {"id": {"S": "order-1"}, "total": {"N": "42.5"}, "paid": {"BOOL": true}},
+			"SequenceNumber": "200",
+			"StreamViewType": "NEW_AND_OLD_IMAGES"
+		}
+	},
+	{
+		"eventID": "3",
+		"eventName": "REMOVE",
+		"eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"Keys": {"id": {"S": "order-1"}},
+			"OldImage": {"id": {"S": "order-1"}, "total": {"N": "42.5"}, "paid": {"BOOL": true}},
+			"SequenceNumber": "300",
+			"StreamViewType": "NEW_AND_OLD_IMAGES"
+		}
+	}
+]}`
+
+type streamOrder struct {
+	ID    string   `dynamodbav:"id"`
+	Total float64  `dynamodbav:"total"`
+	Paid  bool     `dynamodbav:"paid"`
+	Tags  []string `dynamodbav:"tags,stringset"`
+	Lines []struct {
+		SKU string `dynamodbav:"sku"`
+		Qty int    `dynamodbav:"qty"`
+	} `dynamodbav:"lines"`
+}
+
+func sampleStream(t *testing.T) events.DynamoDBEvent {
+	t.Helper()
+	var event events.DynamoDBEvent
+	if err := json.Unmarshal([]byte(sampleStreamEvent), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestDynamoDBStreamHandler(t *testing.T) {
+	h := NewDynamoDBStreamHandler[streamOrder]()
+	var inserted, modified []StreamChange[streamOrder]
+	h.OnInsert(func(ctx context.Context, change StreamChange[streamOrder]) error {
+		inserted = append(inserted, change)
+		return nil
+	})
+	h.OnModify(func(ctx context.Context, change StreamChange[streamOrder]) error {
+		modified = append(modified, change)
+		return nil
+	})
+
+	response, err := h.Handle(context.Background(), sampleStream(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.BatchItemFailures) != 0 {
+		t.Errorf("BatchItemFailures = %+v, want none", response.BatchItemFailures)
+	}
+
+	if len(inserted) != 1 || len(modified) != 1 {
+		t.Fatalf("got %d inserts and %d modifies, want 1 each", len(inserted), len(modified))
+	}
+	insert := inserted[0]
+	if insert.OldImage != nil || insert.NewImage == nil {
+		t.Fatalf("insert images = %v, %v, want only a new image", insert.OldImage, insert.NewImage)
+	}
+	order := *insert.NewImage
+	if order.ID != "order-1" || order.Total != 42.5 || order.Paid || !reflect.DeepEqual(order.Tags, []string{"gift"}) {
+		t.Errorf("inserted order = %+v", order)
+	}
+	if len(order.Lines) != 1 || order.Lines[0].SKU != "A1" || order.Lines[0].Qty != 2 {
+		t.Errorf("inserted lines = %+v", order.Lines)
+	}
+	if modify := modified[0]; modify.OldImage.Paid || !modify.NewImage.Paid {
+		t.Errorf("modify images = %+v, %+v, want paid to go from false to true", modify.OldImage, modify.NewImage)
+	}
+}
+
+func TestDynamoDBStreamHandlerStopsAtFailure(t *testing.T) {
+	h := NewDynamoDBStreamHandler[streamOrder]()
+	var calls []string
+	record := func(ctx context.Context, change StreamChange[streamOrder]) error {
+		calls = append(calls, change.EventName)
+		if change.EventName == StreamModify {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}
+	h.OnInsert(record)
+	h.OnModify(record)
+	h.OnRemove(record)
+
+	response, err := h.Handle(context.Background(), sampleStream(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []events.DynamoDBBatchItemFailure{{ItemIdentifier: "200"}}
+	if !reflect.DeepEqual(response.BatchItemFailures, want) {
+		t.Errorf("BatchItemFailures = %+v, want %+v", response.BatchItemFailures, want)
+	}
+	if !reflect.DeepEqual(calls, []string{StreamInsert, StreamModify}) {
+		t.Errorf("handled %v, want processing to stop at the failed MODIFY", calls)
+	}
+}
+
+func TestDynamoDBStreamHandlerRecoversPanics(t *testing.T) {
+	h := NewDynamoDBStreamHandler[streamOrder]()
+	h.OnInsert(func(ctx context.Context, change StreamChange[streamOrder]) error { return nil })
+	h.OnModify(func(ctx context.Context, change StreamChange[streamOrder]) error { panic("nil map") })
+
+	response, err := h.Handle(context.Background(), sampleStream(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []events.DynamoDBBatchItemFailure{{ItemIdentifier: "200"}}
+	if !reflect.DeepEqual(response.BatchItemFailures, want) {
+		t.Errorf("BatchItemFailures = %+v, want the panicking record's %+v", response.BatchItemFailures, want)
+	}
+}
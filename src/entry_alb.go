@@ -0,0 +1,7 @@
+//go:build alb
+
+package main
+
+func init() {
+	entrypoint = func(h HandlerFunc) interface{} { return ALBHandler(h) }
+}
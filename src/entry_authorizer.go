@@ -0,0 +1,7 @@
+//go:build authorizer
+
+package main
+
+func init() {
+	entrypoint = func(HandlerFunc) interface{} { return authorizer.Handle }
+}
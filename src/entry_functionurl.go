@@ -0,0 +1,7 @@
+//go:build functionurl
+
+package main
+
+func init() {
+	entrypoint = func(h HandlerFunc) interface{} { return FunctionURLHandler(h) }
+}
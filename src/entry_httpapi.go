@@ -0,0 +1,7 @@
+//go:build httpapi
+
+package main
+
+func init() {
+	entrypoint = func(h HandlerFunc) interface{} { return HTTPAPIHandler(h) }
+}
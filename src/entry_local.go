@@ -0,0 +1,7 @@
+//go:build local
+
+package main
+
+func init() {
+	start = serveLocal
+}
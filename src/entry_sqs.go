@@ -0,0 +1,7 @@
+//go:build sqs
+
+package main
+
+func init() {
+	entrypoint = func(HandlerFunc) interface{} { return SQSHandler(sqsDedup.Wrap(processMessageFromSQS)) }
+}
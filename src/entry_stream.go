@@ -0,0 +1,9 @@
+//go:build stream
+
+package main
+
+func init() {
+	entrypoint = func(h HandlerFunc) interface{} {
+		return FunctionURLStreamHandler(concurrencyLimiter.Stream(StreamResponses(h)))
+	}
+}
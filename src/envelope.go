@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EnvelopeConfig controls the response envelope.
+type EnvelopeConfig struct {
+	Enabled bool
+	// RawPaths lists route patterns, such as /health or /files/{id}, whose
+	// responses are sent without the envelope.
+	RawPaths []string
+}
+
+// DefaultEnvelopeConfig enables the envelope everywhere except GET /health
+// and GET /openapi.json, whose shapes monitoring and API tools expect as is.
+func DefaultEnvelopeConfig() EnvelopeConfig {
+	return EnvelopeConfig{Enabled: true, RawPaths: []string{"/health", "/openapi.json"}}
+}
+
+// bodyKind tells the envelope how a JSON body is laid out, so that it never
+// has to guess from the body's keys.
+type bodyKind int
+
+const (
+	plainBody bodyKind = iota
+	pageBody
+	fanOutBody
+)
+
+// kindedBody is implemented by payloads whose responses JSON marks with
+// their bodyKind.
+type kindedBody interface {
+	bodyKind() bodyKind
+}
+
+// envelope is the canonical shape of every JSON response body.
+type envelope struct {
+	Data  interface{}            `json:"data"`
+	Error map[string]interface{} `json:"error"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// EnvelopeMiddleware wraps JSON response bodies in the canonical envelope.
+// Success bodies become {"data": body, "error": null, "meta": {...}} and
+// error bodies, whichever builder produced them, become
+// {"data": null, "error": {"code": ..., "message": ...}}, keeping any extra
+// detail such as validation fields. meta carries the request ID; for a body
+// JSON built from a Page, the pagination state, with the page's items as
+// data; and for one built from a FanOutResult, the partial flag and
+// unavailable sources, with the results as data. Handlers keep using JSON,
+// Error and ErrorResponse unchanged.
+func EnvelopeMiddleware(cfg EnvelopeConfig) Middleware {
+	raw := make([]route, len(cfg.RawPaths))
+	for i, path := range cfg.RawPaths {
+		raw[i] = route{segments: splitPath(path)}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			response, err := next(ctx, request)
+			if !cfg.Enabled || err != nil || response.IsBase64Encoded || !isJSON(response.Headers) {
+				return response, err
+			}
+			segments := splitPath(request.Path)
+			for _, rt := range raw {
+				if _, ok := rt.match(segments); ok {
+					return response, nil
+				}
+			}
+
+			body, eerr := wrapEnvelope(ctx, response)
+			if eerr != nil {
+				logger.ErrorContext(ctx, "wrapping response envelope", "error", eerr)
+				return response, nil
+			}
+			response.Body = body
+			response.kind = plainBody
+			return response, nil
+		}
+	}
+}
+
+func wrapEnvelope(ctx context.Context, response Response) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(response.Body))
+	decoder.UseNumber()
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return "", err
+	}
+
+	env := envelope{Meta: map[string]interface{}{}}
+	if id := RequestIDFromContext(ctx); id != "" {
+		env.Meta["requestId"] = id
+	}
+
+	obj, _ := payload.(map[string]interface{})
+	switch {
+	case response.StatusCode >= 400:
+		env.Error = envelopeError(response.StatusCode, payload)
+	case response.kind == pageBody && obj != nil:
+		env.Data = pageItems(obj, env.Meta)
+	case response.kind == fanOutBody && obj != nil:
+		env.Data = partialResults(obj, env.Meta)
+	default:
+		env.Data = payload
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(env); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// envelopeError normalizes an error body to an object with code and
+// message. APIError bodies already have both; {"error": message} bodies from
+// Error get a code derived from the status, such as "not_found".
+func envelopeError(statusCode int, payload interface{}) map[string]interface{} {
+	obj, ok := payload.(map[string]interface{})
+	if !ok {
+		obj = map[string]interface{}{}
+	}
+	detail := make(map[string]interface{}, len(obj)+2)
+	for k, v := range obj {
+		detail[k] = v
+	}
+
+	if msg, ok := detail["error"].(string); ok {
+		delete(detail, "error")
+		if _, ok := detail["message"]; !ok {
+			detail["message"] = msg
+		}
+	}
+	if _, ok := detail["code"]; !ok {
+		detail["code"] = strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_")
+	}
+	if _, ok := detail["message"]; !ok {
+		detail["message"] = http.StatusText(statusCode)
+	}
+	return detail
+}
+
+// pageItems moves a Page body's pagination state into meta and returns its
+// items as the data.
+func pageItems(obj map[string]interface{}, meta map[string]interface{}) interface{} {
+	if cursor, ok := obj["nextCursor"]; ok {
+		meta["nextCursor"] = cursor
+	}
+	meta["hasMore"] = obj["hasMore"]
+	return obj["items"]
+}
+
+// partialResults moves a FanOutResult body's partial flag and unavailable
+// sources into meta and returns its results as the data.
+func partialResults(obj map[string]interface{}, meta map[string]interface{}) interface{} {
+	if unavailable, ok := obj["unavailable"]; ok {
+		meta["unavailable"] = unavailable
+	}
+	meta["partial"] = obj["partial"]
+	return obj["results"]
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestEnvelopeUnwrapsMarkedBodiesOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Response
+		data     interface{}
+		meta     map[string]interface{}
+	}{
+		{
+			name:     "page",
+			response: JSON(200, NewPage([]string{"a"}, "next")),
+			data:     []interface{}{"a"},
+			meta:     map[string]interface{}{"nextCursor": "next", "hasMore": true},
+		},
+		{
+			name:     "fan-out result",
+			response: JSON(200, FanOutResult[string, int]{Values: map[string]int{"orders": 1}, Partial: true, Unavailable: []string{"invoices"}}),
+			data:     map[string]interface{}{"orders": float64(1)},
+			meta:     map[string]interface{}{"partial": true, "unavailable": []interface{}{"invoices"}},
+		},
+		{
+			name:     "page-shaped map",
+			response: JSON(200, map[string]interface{}{"items": []string{"a"}, "hasMore": false}),
+			data:     map[string]interface{}{"items": []interface{}{"a"}, "hasMore": false},
+		},
+		{
+			name:     "fan-out-shaped map",
+			response: JSON(200, map[string]interface{}{"results": 1, "partial": true}),
+			data:     map[string]interface{}{"results": float64(1), "partial": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := EnvelopeMiddleware(DefaultEnvelopeConfig())(func(context.Context, events.APIGatewayProxyRequest) (Response, error) {
+				return tt.response, nil
+			})
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{Path: "/items"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var env struct {
+				Data interface{}            `json:"data"`
+				Meta map[string]interface{} `json:"meta"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &env); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(env.Data, tt.data) {
+				t.Errorf("data = %#v, want %#v", env.Data, tt.data)
+			}
+			if !reflect.DeepEqual(env.Meta, tt.meta) {
+				t.Errorf("meta = %#v, want %#v", env.Meta, tt.meta)
+			}
+		})
+	}
+}
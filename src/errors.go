@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// APIError is an error with a stable machine-readable code and the HTTP
+// status it maps to. Business logic returns (or wraps) one so the client
+// gets a precise response instead of a generic 500.
+type APIError struct {
+	Code       string `json:"code"`
+	HTTPStatus int    `json:"-"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// NewAPIError returns an APIError for httpStatus with the given code and message.
+func NewAPIError(httpStatus int, code, message string) *APIError {
+	return &APIError{Code: code, HTTPStatus: httpStatus, Message: message}
+}
+
+// Sentinel errors for the common failure cases. Wrap them with fmt.Errorf
+// and %w to add context; ErrorResponse still finds them.
+var (
+	ErrBadRequest   = NewAPIError(http.StatusBadRequest, "bad_request", "The request is invalid")
+	ErrUnauthorized = NewAPIError(http.StatusUnauthorized, "unauthorized", "Authentication is required")
+	ErrForbidden    = NewAPIError(http.StatusForbidden, "forbidden", "You do not have access to this resource")
+	ErrNotFound     = NewAPIError(http.StatusNotFound, "not_found", "The requested resource was not found")
+	ErrConflict     = NewAPIError(http.StatusConflict, "conflict", "The request conflicts with the current state")
+	ErrInternal     = NewAPIError(http.StatusInternalServerError, "internal_error", "Internal server error")
+)
+
+// ErrorResponse converts err into a JSON response of the form
+// {"code": ..., "message": ...}. Errors that are not, and do not wrap, an
+// APIError become a generic 500 so internal details never reach the client.
+func ErrorResponse(err error) Response {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = ErrInternal
+	}
+	return JSON(apiErr.HTTPStatus, apiErr)
+}
+
+// ErrorMappingMiddleware turns an error returned by next into the matching
+// response via ErrorResponse. Errors that become a 5xx are logged at error
+// level with the full wrapped chain and, for errors from WithStack, the stack
+// where they were raised; client errors are logged at warn level.
+func ErrorMappingMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		response, err := next(ctx, request)
+		if err == nil {
+			return response, nil
+		}
+
+		response = ErrorResponse(err)
+		logError(ctx, response.StatusCode, err)
+		return response, nil
+	}
+}
+
+func logError(ctx context.Context, statusCode int, err error) {
+	if statusCode < 500 {
+		logger.WarnContext(ctx, "request rejected", "statusCode", statusCode, "error", err.Error())
+		return
+	}
+
+	attrs := []interface{}{
+		"statusCode", statusCode,
+		"error", fmt.Sprintf("%+v", err),
+		"errorChain", errorChain(err),
+	}
+	var stackErr *stackError
+	if errors.As(err, &stackErr) {
+		attrs = append(attrs, "stack", string(stackErr.stack))
+	}
+	logger.ErrorContext(ctx, "request failed", attrs...)
+}
+
+// errorChain describes err and each error it wraps, outermost first, by
+// type and message.
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, fmt.Sprintf("%T: %v", err, err))
+	}
+	return chain
+}
+
+// stackError carries the stack trace captured by WithStack.
+type stackError struct {
+	err   error
+	stack []byte
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+func (e *stackError) Unwrap() error { return e.err }
+
+// WithStack records the caller's stack trace on err, so it is logged if err
+// ends up as a 5xx. It returns nil for a nil err.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, stack: debug.Stack()}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// WithETag makes a successful GET response conditional. It tags response
+// with a weak ETag derived from its body and, when the request's
+// If-None-Match already names that tag, returns a bodiless 304 in its place.
+// The 304 keeps the other headers, so Cache-Control and Vary still apply,
+// and CORS headers are added by CORSMiddleware as for any other response.
+// Requests other than GET and non-200 responses are returned unchanged.
+func WithETag(request events.APIGatewayProxyRequest, response Response) Response {
+	if !strings.EqualFold(request.HTTPMethod, "GET") || response.StatusCode != 200 {
+		return response
+	}
+
+	sum := sha256.Sum256([]byte(response.Body))
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	if response.Headers == nil {
+		response.Headers = map[string]string{}
+	}
+	response.Headers["ETag"] = etag
+
+	if !etagMatches(GetHeader(request, "If-None-Match"), etag) {
+		return response
+	}
+
+	headers := make(map[string]string, len(response.Headers))
+	for k, v := range response.Headers {
+		if !strings.EqualFold(k, "Content-Type") {
+			headers[k] = v
+		}
+	}
+	return Response{StatusCode: 304, Headers: headers}
+}
+
+// ETagMiddleware applies WithETag to every response from next. Attach it to
+// cacheable GET routes with Chain.
+func ETagMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		response, err := next(ctx, request)
+		if err != nil {
+			return response, err
+		}
+		return WithETag(request, response), nil
+	}
+}
+
+// etagMatches reports whether an If-None-Match value names etag, using the
+// weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
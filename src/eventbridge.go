@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EventFunc handles an EventBridge event's raw detail.
+type EventFunc func(ctx context.Context, detail json.RawMessage, event events.CloudWatchEvent) error
+
+// EventRouter dispatches EventBridge events to handlers by detail-type.
+type EventRouter struct {
+	handlers map[string]EventFunc
+}
+
+// NewEventRouter returns an EventRouter with no handlers registered.
+func NewEventRouter() *EventRouter {
+	return &EventRouter{handlers: map[string]EventFunc{}}
+}
+
+// Handle registers fn for events whose detail-type is detailType.
+func (r *EventRouter) Handle(detailType string, fn EventFunc) {
+	r.handlers[detailType] = fn
+}
+
+// HandleEvent registers a handler for detailType that receives the detail
+// unmarshaled into T. Methods cannot have type parameters, hence the
+// function form.
+func HandleEvent[T any](r *EventRouter, detailType string, fn func(ctx context.Context, detail T, event events.CloudWatchEvent) error) {
+	r.Handle(detailType, func(ctx context.Context, raw json.RawMessage, event events.CloudWatchEvent) error {
+		var detail T
+		if err := json.Unmarshal(raw, &detail); err != nil {
+			return fmt.Errorf("decoding %s detail: %w", detailType, err)
+		}
+		return fn(ctx, detail, event)
+	})
+}
+
+// Dispatch runs the handler for event's detail-type. Events with no handler
+// are logged and acknowledged, since retrying them cannot succeed; a handler
+// error fails the invocation so EventBridge's retry policy applies.
+func (r *EventRouter) Dispatch(ctx context.Context, event events.CloudWatchEvent) error {
+	markInvocation()
+	ctx = withRequestIDs(ctx, event.ID)
+
+	fn, ok := r.handlers[event.DetailType]
+	if !ok {
+		logger.WarnContext(ctx, "no handler for event",
+			"detailType", event.DetailType,
+			"source", event.Source,
+		)
+		return nil
+	}
+
+	if err := fn(ctx, event.Detail, event); err != nil {
+		logger.ErrorContext(ctx, "handling event",
+			"detailType", event.DetailType,
+			"source", event.Source,
+			"error", err,
+		)
+		return err
+	}
+	logger.InfoContext(ctx, "event", "detailType", event.DetailType, "source", event.Source)
+	return nil
+}
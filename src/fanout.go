@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultFanOutDeadline is the soft deadline FanOut uses when
+// FAN_OUT_SOFT_DEADLINE is unset.
+const DefaultFanOutDeadline = 2 * time.Second
+
+// fanOutDeadline is the soft deadline used when FanOut is passed none. main
+// sets it from FAN_OUT_SOFT_DEADLINE.
+var fanOutDeadline = DefaultFanOutDeadline
+
+// FanOutTask fetches one source of an aggregate response. Its key is given
+// up front, rather than returned, so a source that misses the deadline can
+// still be named.
+type FanOutTask[K comparable, V any] struct {
+	Key K
+	Run func(ctx context.Context) (V, error)
+}
+
+// NewFanOutTask returns a task fetching the source key with run.
+func NewFanOutTask[K comparable, V any](key K, run func(ctx context.Context) (V, error)) FanOutTask[K, V] {
+	return FanOutTask[K, V]{Key: key, Run: run}
+}
+
+// FanOutResult holds what FanOut collected. Returned as a JSON body, it is
+// {"results": ..., "partial": ..., "unavailable": [...]}, which the response
+// envelope turns into the results as data with partial and unavailable in
+// meta.
+type FanOutResult[K comparable, V any] struct {
+	// Values holds the result of every source that succeeded in time.
+	Values map[K]V `json:"results"`
+	// Partial is set when any source is missing from Values.
+	Partial bool `json:"partial"`
+	// Unavailable lists the missing sources in task order.
+	Unavailable []K `json:"unavailable,omitempty"`
+
+	// TimedOut lists the sources still running at the deadline, and Errors
+	// holds the error of each source that failed.
+	TimedOut []K         `json:"-"`
+	Errors   map[K]error `json:"-"`
+}
+
+func (FanOutResult[K, V]) bodyKind() bodyKind { return fanOutBody }
+
+// FanOut runs tasks concurrently and returns whatever they produced by
+// softDeadline, so an aggregate endpoint can answer with partial data instead
+// of failing when one source is slow or down. A softDeadline of zero uses the
+// configured default. A task that panics counts as failed.
+//
+// At the deadline the tasks' context is cancelled and FanOut returns without
+// waiting for them. Tasks must honour ctx to stop promptly; one that does not
+// runs to completion in the background, but never blocks, since its result
+// has room in a buffered channel.
+func FanOut[K comparable, V any](ctx context.Context, softDeadline time.Duration, tasks ...FanOutTask[K, V]) FanOutResult[K, V] {
+	if softDeadline == 0 {
+		softDeadline = fanOutDeadline
+	}
+	ctx, cancel := context.WithTimeout(ctx, softDeadline)
+	defer cancel()
+
+	type outcome struct {
+		index int
+		value V
+		err   error
+	}
+	outcomes := make(chan outcome, len(tasks))
+	for i, task := range tasks {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					outcomes <- outcome{index: i, err: fmt.Errorf("panic: %v", r)}
+				}
+			}()
+			value, err := task.Run(ctx)
+			outcomes <- outcome{index: i, value: value, err: err}
+		}()
+	}
+
+	result := FanOutResult[K, V]{Values: make(map[K]V, len(tasks)), Errors: map[K]error{}}
+	finished := make([]bool, len(tasks))
+collect:
+	for range tasks {
+		select {
+		case o := <-outcomes:
+			key := tasks[o.index].Key
+			switch {
+			case o.err == nil:
+				finished[o.index] = true
+				result.Values[key] = o.value
+			case ctx.Err() != nil && errors.Is(o.err, ctx.Err()):
+				// Gave up at the deadline: a timeout, not a failure.
+			default:
+				finished[o.index] = true
+				result.Errors[key] = o.err
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	for i, task := range tasks {
+		if _, ok := result.Values[task.Key]; ok {
+			continue
+		}
+		result.Unavailable = append(result.Unavailable, task.Key)
+		if !finished[i] {
+			result.TimedOut = append(result.TimedOut, task.Key)
+			logger.WarnContext(ctx, "fan-out source timed out", "source", task.Key, "deadline", softDeadline.String())
+		} else {
+			logger.WarnContext(ctx, "fan-out source failed", "source", task.Key, "error", result.Errors[task.Key])
+		}
+	}
+	result.Partial = len(result.Unavailable) > 0
+	return result
+}
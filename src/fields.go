@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FieldsMiddleware implements sparse fieldsets: when the request has a
+// fields query parameter, such as ?fields=id,name, a successful JSON
+// response keeps only those top-level fields. An array body is filtered
+// element by element, and a Page body filters its items. Unknown names are
+// ignored. Add it to the chains of routes whose clients may trim responses,
+// inside ETagMiddleware so the tag matches the filtered body.
+func FieldsMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		response, err := next(ctx, request)
+		if err != nil || response.StatusCode < 200 || response.StatusCode >= 300 ||
+			response.IsBase64Encoded || !isJSON(response.Headers) {
+			return response, err
+		}
+		fields := requestedFields(request)
+		if len(fields) == 0 {
+			return response, nil
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(response.Body))
+		decoder.UseNumber()
+		var payload interface{}
+		if derr := decoder.Decode(&payload); derr != nil {
+			logger.WarnContext(ctx, "filtering response fields", "error", derr)
+			return response, nil
+		}
+
+		body, merr := marshalJSON(filterFields(payload, fields, response.kind == pageBody))
+		if merr != nil {
+			logger.ErrorContext(ctx, "filtering response fields", "error", merr)
+			return response, nil
+		}
+		response.Body = body
+		return response, nil
+	}
+}
+
+// requestedFields collects the names from every fields parameter, so
+// ?fields=id,name and ?fields=id&fields=name are equivalent.
+func requestedFields(request events.APIGatewayProxyRequest) map[string]bool {
+	fields := map[string]bool{}
+	for _, value := range QueryValues(request, "fields") {
+		for _, name := range splitList(value) {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// filterFields trims payload to fields; for a Page body, set page to filter
+// its items instead.
+func filterFields(payload interface{}, fields map[string]bool, page bool) interface{} {
+	switch v := payload.(type) {
+	case []interface{}:
+		for i, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				v[i] = filterObject(obj, fields)
+			}
+		}
+		return v
+	case map[string]interface{}:
+		if page {
+			v["items"] = filterFields(v["items"], fields, false)
+			return v
+		}
+		return filterObject(v, fields)
+	default:
+		return payload
+	}
+}
+
+func filterObject(obj map[string]interface{}, fields map[string]bool) map[string]interface{} {
+	for k := range obj {
+		if !fields[k] {
+			delete(obj, k)
+		}
+	}
+	return obj
+}
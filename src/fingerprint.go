@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FingerprintConfig chooses the parts of a request that RequestFingerprint
+// hashes besides its method, path and query string.
+type FingerprintConfig struct {
+	// Headers lists the headers included, by name in any case. Their order
+	// does not matter.
+	Headers []string
+	// IncludeBody adds the decoded request body.
+	IncludeBody bool
+}
+
+// DefaultFingerprintConfig includes the body and the caller's credentials,
+// so requests from different callers never share a fingerprint.
+func DefaultFingerprintConfig() FingerprintConfig {
+	return FingerprintConfig{Headers: []string{"Authorization", "X-Api-Key"}, IncludeBody: true}
+}
+
+// fingerprintConfig is used by RequestFingerprint. main sets it from
+// FINGERPRINT_HEADERS and FINGERPRINT_INCLUDE_BODY.
+var fingerprintConfig = DefaultFingerprintConfig()
+
+// RequestFingerprint returns a short hex digest of the request's method,
+// path, query string and the configured headers and body. It is stable
+// across invocations and containers and ignores the order of query
+// parameters and headers, so identical requests can be grouped in logs or
+// deduplicated without an Idempotency-Key.
+func RequestFingerprint(request events.APIGatewayProxyRequest) string {
+	return fingerprintConfig.Fingerprint(request)
+}
+
+// Fingerprint returns the request's fingerprint under c. Repeated query
+// parameters and header values keep their relative order, which can change
+// their meaning.
+func (c FingerprintConfig) Fingerprint(request events.APIGatewayProxyRequest) string {
+	query := url.Values{}
+	for k, v := range request.QueryStringParameters {
+		query[k] = []string{v}
+	}
+	for k, v := range request.MultiValueQueryStringParameters {
+		query[k] = v
+	}
+
+	h := sha256.New()
+	// Each part ends in a NUL so that adjacent parts cannot run together.
+	for _, part := range []string{strings.ToUpper(request.HTTPMethod), request.Path, query.Encode()} {
+		h.Write([]byte(part + "\x00"))
+	}
+
+	names := make([]string, 0, len(c.Headers))
+	for _, name := range c.Headers {
+		names = append(names, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if values := HeaderValues(request, name); len(values) > 0 {
+			h.Write([]byte(name + ":" + strings.Join(values, "\n") + "\x00"))
+		}
+	}
+
+	if c.IncludeBody {
+		body, err := DecodeBody(request)
+		if err != nil {
+			body = []byte(request.Body)
+		}
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
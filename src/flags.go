@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FlagOverrideHeader lets a request force flags on or off, as in
+// "X-Feature-Flags: new-checkout=on, beta-search=off", when overrides are
+// allowed.
+const FlagOverrideHeader = "X-Feature-Flags"
+
+// FeatureFlags maps a flag name to the percentage of callers, from 0 to 100,
+// it is enabled for.
+type FeatureFlags map[string]float64
+
+// parseFlagValue reads a flag setting: true or false, or a rollout
+// percentage such as 25 or 25%.
+func parseFlagValue(raw string) (float64, error) {
+	if b, err := strconv.ParseBool(raw); err == nil {
+		if b {
+			return 100, nil
+		}
+		return 0, nil
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, fmt.Errorf("%q must be true, false or a percentage from 0 to 100", raw)
+	}
+	return pct, nil
+}
+
+type flagStateKey struct{}
+
+type flagState struct {
+	flags     FeatureFlags
+	overrides map[string]bool
+	sourceIP  string
+}
+
+// FeatureFlagMiddleware makes flags available to IsEnabled for the request.
+// With allowOverrides, meant for development stages only, the
+// X-Feature-Flags header can force individual flags on or off.
+func FeatureFlagMiddleware(flags FeatureFlags, allowOverrides bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			state := flagState{flags: flags, sourceIP: request.RequestContext.Identity.SourceIP}
+			if allowOverrides {
+				state.overrides = parseFlagOverrides(GetHeader(request, FlagOverrideHeader))
+			}
+			return next(context.WithValue(ctx, flagStateKey{}, state), request)
+		}
+	}
+}
+
+// IsEnabled reports whether flag is on for the current caller. A partial
+// rollout buckets callers by a stable identifier, the JWT subject, then the
+// API key ID, then the source IP, so a caller sees the same answer on every
+// request. Unknown flags, and calls outside FeatureFlagMiddleware, are off.
+func IsEnabled(ctx context.Context, flag string) bool {
+	state, ok := ctx.Value(flagStateKey{}).(flagState)
+	if !ok {
+		return false
+	}
+	if on, ok := state.overrides[flag]; ok {
+		return on
+	}
+
+	pct := state.flags[flag]
+	switch {
+	case pct <= 0:
+		return false
+	case pct >= 100:
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + rolloutKey(ctx, state)))
+	// Buckets of a hundredth of a percent allow rollouts such as 0.5%.
+	return float64(h.Sum32()%10000) < pct*100
+}
+
+func rolloutKey(ctx context.Context, state flagState) string {
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		if subject, _ := claims.GetSubject(); subject != "" {
+			return "sub:" + subject
+		}
+	}
+	if id := APIKeyIDFromContext(ctx); id != "" {
+		return "key:" + id
+	}
+	return "ip:" + state.sourceIP
+}
+
+// parseFlagOverrides reads name=on|off pairs; any value strconv.ParseBool
+// accepts works too. Malformed pairs are ignored.
+func parseFlagOverrides(value string) map[string]bool {
+	overrides := map[string]bool{}
+	for _, item := range splitList(value) {
+		name, raw, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "on":
+			overrides[name] = true
+		case "off":
+			overrides[name] = false
+		default:
+			if b, err := strconv.ParseBool(strings.TrimSpace(raw)); err == nil {
+				overrides[name] = b
+			}
+		}
+	}
+	return overrides
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// maxFormMemory is how much of a multipart body ParseMultipart keeps in
+// memory; larger file parts spill to temporary files under /tmp. It matches
+// net/http's default for Request.ParseMultipartForm.
+const maxFormMemory = 32 << 20
+
+// ParseForm parses an application/x-www-form-urlencoded request body,
+// base64-decoding it first when API Gateway flagged it as binary. It returns
+// a 415 APIError for any other Content-Type and a 400 APIError when the body
+// is malformed.
+func ParseForm(request events.APIGatewayProxyRequest) (url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(GetHeader(request, "Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return nil, NewAPIError(415, "unsupported_media_type", "Content type must be application/x-www-form-urlencoded")
+	}
+
+	body, err := DecodeBody(request)
+	if err != nil {
+		return nil, NewAPIError(400, "invalid_body", "Invalid base64 request body")
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, NewAPIError(400, "invalid_form", "Malformed form body")
+	}
+	return values, nil
+}
+
+// ParseMultipart parses a multipart/form-data request body using the
+// boundary from its Content-Type header. It returns a 415 APIError for any
+// other Content-Type and a 400 APIError when the boundary is missing or the
+// body is malformed. Callers should call RemoveAll on the returned form to
+// delete any parts that spilled to temporary files.
+func ParseMultipart(request events.APIGatewayProxyRequest) (*multipart.Form, error) {
+	mediaType, params, err := mime.ParseMediaType(GetHeader(request, "Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, NewAPIError(415, "unsupported_media_type", "Content type must be multipart/form-data")
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, NewAPIError(400, "invalid_form", "Multipart boundary is missing")
+	}
+
+	body, err := DecodeBody(request)
+	if err != nil {
+		return nil, NewAPIError(400, "invalid_body", "Invalid base64 request body")
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(maxFormMemory)
+	if err != nil {
+		if errors.Is(err, multipart.ErrMessageTooLarge) {
+			return nil, NewAPIError(413, "payload_too_large", "Multipart body is too large")
+		}
+		return nil, NewAPIError(400, "invalid_form", "Malformed multipart body")
+	}
+	return form, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FunctionURLHandler adapts h to Lambda Function URLs. The request uses the
+// HTTP API payload v2 shape and is normalized the same way, with its cookies
+// restored as a Cookie header. The response's Cookies, and any Set-Cookie
+// header, are returned through the response's cookies, since Function URLs
+// ignore Set-Cookie in headers.
+func FunctionURLHandler(h HandlerFunc) func(context.Context, events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return func(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		markInvocation()
+		response, err := h(ctx, fromFunctionURLRequest(request))
+		return toFunctionURLResponse(response), err
+	}
+}
+
+func fromFunctionURLRequest(request events.LambdaFunctionURLRequest) events.APIGatewayProxyRequest {
+	headers, multiValueHeaders := normalizeV2Headers(request.Headers, request.Cookies)
+	query, multiValueQuery := normalizeV2Query(request.RawQueryString, request.QueryStringParameters)
+
+	return events.APIGatewayProxyRequest{
+		Path:                            request.RawPath,
+		HTTPMethod:                      request.RequestContext.HTTP.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               multiValueHeaders,
+		QueryStringParameters:           query,
+		MultiValueQueryStringParameters: multiValueQuery,
+		Body:                            request.Body,
+		IsBase64Encoded:                 request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        request.RequestContext.AccountID,
+			DomainName:       request.RequestContext.DomainName,
+			DomainPrefix:     request.RequestContext.DomainPrefix,
+			RequestID:        request.RequestContext.RequestID,
+			Protocol:         request.RequestContext.HTTP.Protocol,
+			Path:             request.RequestContext.HTTP.Path,
+			HTTPMethod:       request.RequestContext.HTTP.Method,
+			RequestTime:      request.RequestContext.Time,
+			RequestTimeEpoch: request.RequestContext.TimeEpoch,
+			APIID:            request.RequestContext.APIID,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  request.RequestContext.HTTP.SourceIP,
+				UserAgent: request.RequestContext.HTTP.UserAgent,
+			},
+		},
+	}
+}
+
+func toFunctionURLResponse(response Response) events.LambdaFunctionURLResponse {
+	cookies, headers := responseCookies(response)
+	return events.LambdaFunctionURLResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         headers,
+		Body:            response.Body,
+		IsBase64Encoded: response.IsBase64Encoded,
+		Cookies:         cookies,
+	}
+}
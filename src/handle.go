@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleOption customizes a handler built by Handle.
+type HandleOption func(*handleConfig)
+
+type handleConfig struct {
+	status          int
+	disallowUnknown bool
+	// location derives the Location header from the Res, which it takes
+	// as an interface{} since options are not generic; locationType is the
+	// Res it was written for.
+	location     func(res interface{}) string
+	locationType reflect.Type
+}
+
+// WithStatus sets the status of successful responses, overriding the
+// default for the request's method: 201 for POST, 204 for DELETE and 200 for
+// everything else. A 204 is sent without a body.
+func WithStatus(status int) HandleOption {
+	return func(c *handleConfig) { c.status = status }
+}
+
+// WithDisallowUnknownFields rejects, with a 400, a JSON body holding a key
+// that matches no field of the request type, instead of ignoring it.
+func WithDisallowUnknownFields() HandleOption {
+	return func(c *handleConfig) { c.disallowUnknown = true }
+}
+
+// WithLocation sets the Location header of 201 responses to the URL of the
+// created resource, which fn derives from the result, such as
+// "/users/" + user.ID. No header is sent when fn returns "", as for a
+// result without an ID. Res must be the handler's result
+// type; Handle panics otherwise.
+func WithLocation[Res any](fn func(res Res) string) HandleOption {
+	return func(c *handleConfig) {
+		c.location = func(res interface{}) string { return fn(res.(Res)) }
+		c.locationType = reflect.TypeFor[Res]()
+	}
+}
+
+// Handle adapts fn, a function of a typed request, to a HandlerFunc. The
+// request is bound into Req: `query`-tagged fields from the query string,
+// `path`-tagged fields from the path parameters as BindPath does and, when
+// there is a body, the rest from JSON as BindJSON does. A Req that is a
+// struct, or a pointer to one, is then validated, answering a 400 for a bad
+// path parameter and a 422 for any other failure, before fn runs. The Res
+// that fn returns is marshaled with JSON, with a status chosen by the
+// request's method unless WithStatus sets one; see WithStatus. Errors, from
+// binding or from fn, are returned as they are, for the chain's
+// ErrorMappingMiddleware to map, so APIErrors keep their status and
+// AlertMiddleware sees the error itself.
+func Handle[Req, Res any](fn func(ctx context.Context, req Req) (Res, error), opts ...HandleOption) HandlerFunc {
+	var cfg handleConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.location != nil && cfg.locationType != reflect.TypeFor[Res]() {
+		panic("WithLocation is for " + cfg.locationType.String() + " but the handler returns " + reflect.TypeFor[Res]().String())
+	}
+
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		var req Req
+		if err := bindRequest(request, &req, cfg.disallowUnknown); err != nil {
+			return Response{}, err
+		}
+		if isStruct(req) {
+			if err := validatePath(req); err != nil {
+				return Response{}, err
+			}
+			if fieldErrs := Validate(req); len(fieldErrs) > 0 {
+				return ValidationError(fieldErrs), nil
+			}
+		}
+
+		res, err := fn(ctx, req)
+		if err != nil {
+			return Response{}, err
+		}
+
+		status := cfg.status
+		if status == 0 {
+			status = defaultStatus(request.HTTPMethod)
+		}
+		response := Response{StatusCode: status, Headers: map[string]string{}}
+		if status != 204 {
+			response = JSON(status, res)
+		}
+		if cfg.location != nil && response.StatusCode == 201 {
+			if location := cfg.location(res); location != "" {
+				response.Headers["Location"] = location
+			}
+		}
+		return response, nil
+	}
+}
+
+// defaultStatus is the success status for a method: 201 Created for POST,
+// 204 No Content for DELETE and 200 otherwise.
+func defaultStatus(method string) int {
+	switch method {
+	case "POST":
+		return 201
+	case "DELETE":
+		return 204
+	default:
+		return 200
+	}
+}
+
+// bindRequest fills v, a pointer, from the query string when it points to a
+// struct, from the JSON body when the request has one, and then from the
+// path parameters, which take precedence over the body.
+func bindRequest(request events.APIGatewayProxyRequest, v interface{}, disallowUnknown bool) error {
+	rv := reflect.ValueOf(v).Elem()
+	// A Req that is a pointer to a struct is allocated and bound through.
+	if rv.Kind() == reflect.Pointer && rv.Type().Elem().Kind() == reflect.Struct {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		if err := bindQuery(request, rv); err != nil {
+			return err
+		}
+	}
+	if request.Body != "" {
+		if err := bindJSON(request, v, disallowUnknown); err != nil {
+			return err
+		}
+	}
+	if rv.Kind() == reflect.Struct {
+		return bindPath(request, rv)
+	}
+	return nil
+}
+
+// isStruct reports whether v is a struct or a non-nil pointer to one, the
+// values Validate accepts.
+func isStruct(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Struct
+}
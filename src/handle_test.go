@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type handleTestItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandleStatus(t *testing.T) {
+	create := func(ctx context.Context, req handleTestItem) (handleTestItem, error) {
+		req.ID = "42"
+		return req, nil
+	}
+	location := WithLocation(func(res handleTestItem) string {
+		if res.ID == "" {
+			return ""
+		}
+		return "/items/" + res.ID
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		opts         []HandleOption
+		wantStatus   int
+		wantLocation string
+		wantBody     bool
+	}{
+		{"POST creates", "POST", []HandleOption{location}, 201, "/items/42", true},
+		{"POST without WithLocation", "POST", nil, 201, "", true},
+		{"DELETE is empty", "DELETE", []HandleOption{location}, 204, "", false},
+		{"GET", "GET", []HandleOption{location}, 200, "", true},
+		{"PUT", "PUT", []HandleOption{location}, 200, "", true},
+		{"PATCH", "PATCH", nil, 200, "", true},
+		{"WithStatus overrides POST", "POST", []HandleOption{WithStatus(202), location}, 202, "", true},
+		{"WithStatus 201 on PUT", "PUT", []HandleOption{WithStatus(201), location}, 201, "/items/42", true},
+		{"WithStatus 204 drops the body", "POST", []HandleOption{WithStatus(204)}, 204, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Handle(create, tt.opts...)
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod: tt.method,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       `{"name": "widget"}`,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantStatus)
+			}
+			if got := response.Headers["Location"]; got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantBody && response.Body != `{"id":"42","name":"widget"}` {
+				t.Errorf("body = %q, want the created item", response.Body)
+			}
+			if !tt.wantBody && response.Body != "" {
+				t.Errorf("body = %q, want empty", response.Body)
+			}
+		})
+	}
+}
+
+func TestWithLocationTypeMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Handle accepted WithLocation for a different result type")
+		}
+	}()
+	Handle(func(ctx context.Context, req handleTestItem) (string, error) { return "", nil },
+		WithLocation(func(res handleTestItem) string { return "/items/" + res.ID }))
+}
+
+func TestHandleReturnsErrorsUnmapped(t *testing.T) {
+	failure := errors.New("database unavailable")
+	h := Handle(func(ctx context.Context, req handleTestItem) (handleTestItem, error) {
+		return handleTestItem{}, failure
+	})
+	request := events.APIGatewayProxyRequest{HTTPMethod: "POST", Headers: map[string]string{"Content-Type": "application/json"}, Body: "{}"}
+	if _, err := h(context.Background(), request); !errors.Is(err, failure) {
+		t.Fatalf("error = %v, want the handler's own error", err)
+	}
+
+	request.Body = "{"
+	var apiErr *APIError
+	if _, err := h(context.Background(), request); !errors.As(err, &apiErr) || apiErr.HTTPStatus != 400 {
+		t.Errorf("binding error = %v, want a 400 APIError", err)
+	}
+}
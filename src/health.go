@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultHealthCheckTimeout bounds each dependency check so GET /health
+// answers quickly even when a dependency hangs.
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheckFunc reports whether a dependency is usable, returning an error
+// when it is not.
+type HealthCheckFunc func(ctx context.Context) error
+
+var healthChecks = struct {
+	mu     sync.RWMutex
+	checks map[string]HealthCheckFunc
+}{checks: map[string]HealthCheckFunc{}}
+
+// RegisterHealthCheck adds a dependency check to GET /health under name,
+// replacing any check already registered with that name.
+func RegisterHealthCheck(name string, fn HealthCheckFunc) {
+	healthChecks.mu.Lock()
+	defer healthChecks.mu.Unlock()
+	healthChecks.checks[name] = fn
+}
+
+// healthHandler serves GET /health. It runs every registered check in
+// parallel, each under DefaultHealthCheckTimeout, and answers 200 when all
+// pass and 503 otherwise. Failure details are logged, not returned, since the
+// endpoint is unauthenticated.
+func healthHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	healthChecks.mu.RLock()
+	names := make([]string, 0, len(healthChecks.checks))
+	for name := range healthChecks.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]HealthCheckFunc, len(names))
+	for i, name := range names {
+		checks[i] = healthChecks.checks[name]
+	}
+	healthChecks.mu.RUnlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheckFunc) {
+			defer wg.Done()
+			errs[i] = runHealthCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	status, statusCode := "ok", 200
+	results := make(map[string]string, len(names))
+	for i, name := range names {
+		switch {
+		case errs[i] == nil:
+			results[name] = "ok"
+			continue
+		case errors.Is(errs[i], context.DeadlineExceeded):
+			results[name] = "timeout"
+		default:
+			results[name] = "failed"
+		}
+		status, statusCode = "unhealthy", 503
+		logger.WarnContext(ctx, "health check failed", "check", name, "error", errs[i])
+	}
+
+	body := map[string]interface{}{"status": status}
+	if len(results) > 0 {
+		body["checks"] = results
+	}
+	return JSON(statusCode, body), nil
+}
+
+// runHealthCheck runs check under the health-check timeout. A check that
+// ignores its context is abandoned when the timeout expires rather than
+// holding up the response.
+func runHealthCheck(ctx context.Context, check HealthCheckFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// HTTPClientConfig sets how HTTPClient times out and retries calls.
+type HTTPClientConfig struct {
+	// Timeout bounds each attempt, including reading the response body.
+	Timeout time.Duration
+	// MaxRetries is how many times a failed attempt is repeated.
+	MaxRetries int
+	// BaseDelay is the longest backoff before the first retry, doubled for
+	// each one after it up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After asking for longer ends the
+	// retries, returning the response that carried it.
+	MaxDelay time.Duration
+	// RetryNonIdempotent allows retrying methods such as POST, which are
+	// otherwise only retried when they carry an Idempotency-Key.
+	RetryNonIdempotent bool
+	// CorrelationHeader carries the request's correlation ID downstream.
+	CorrelationHeader string
+}
+
+// DefaultHTTPClientConfig allows five seconds an attempt and retries twice,
+// after up to 100ms and then up to 200ms.
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:           5 * time.Second,
+		MaxRetries:        2,
+		BaseDelay:         100 * time.Millisecond,
+		MaxDelay:          2 * time.Second,
+		CorrelationHeader: DefaultCorrelationHeader,
+	}
+}
+
+// httpClient is the client for calls to external HTTP APIs. main replaces
+// it with one built from the HTTP_CLIENT_* settings.
+var httpClient = NewHTTPClient(DefaultHTTPClientConfig())
+
+// HTTPClient calls external HTTP APIs with a timeout per attempt, retries
+// with exponential backoff, and the correlation ID forwarded. Calls are
+// timed as downstream calls under the host's name. It is safe for
+// concurrent use.
+type HTTPClient struct {
+	client *http.Client
+	cfg    HTTPClientConfig
+}
+
+// NewHTTPClient returns a client configured by cfg, with its own pool of
+// connections.
+func NewHTTPClient(cfg HTTPClientConfig) *HTTPClient {
+	return &HTTPClient{
+		client: &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		cfg:    cfg,
+	}
+}
+
+// closeIdleConnections closes the client's pooled connections at shutdown.
+func (c *HTTPClient) closeIdleConnections(context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// idempotentMethods may be retried without repeating a side effect.
+var idempotentMethods = []string{"GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE"}
+
+// Do sends req, retrying transport errors and 5xx responses while ctx
+// allows. A Retry-After on a 5xx sets the wait before the next try. Methods
+// that are not idempotent are retried only with an Idempotency-Key header,
+// or when the client allows it. req's body is buffered if it cannot be
+// replayed through GetBody, so every attempt sends it whole.
+//
+// As with http.Client, the caller must close the returned response's body;
+// the attempt's timeout keeps running until it does.
+func (c *HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("buffering request body: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		req.Body, _ = req.GetBody()
+	}
+
+	retryable := c.cfg.RetryNonIdempotent || slices.Contains(idempotentMethods, req.Method) ||
+		req.Header.Get("Idempotency-Key") != ""
+
+	start := time.Now()
+	defer func() { RecordDownstream(ctx, req.URL.Host, time.Since(start)) }()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, req, attempt)
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if !retryable || attempt >= c.cfg.MaxRetries {
+			return resp, err
+		}
+
+		wait := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > c.cfg.MaxDelay {
+					return resp, nil
+				}
+				wait = after
+			}
+			logger.WarnContext(ctx, "retrying HTTP call", "host", req.URL.Host, "attempt", attempt+1, "statusCode", resp.StatusCode, "wait", wait.String())
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			logger.WarnContext(ctx, "retrying HTTP call", "host", req.URL.Host, "attempt", attempt+1, "error", err, "wait", wait.String())
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one copy of req under its own timeout, which ends when the
+// response body is closed.
+func (c *HTTPClient) attempt(ctx context.Context, req *http.Request, n int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	try := req.Clone(ctx)
+	if n > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("rewinding request body: %w", err)
+		}
+		try.Body = body
+	}
+	if id := CorrelationIDFromContext(ctx); id != "" && c.cfg.CorrelationHeader != "" && try.Header.Get(c.cfg.CorrelationHeader) == "" {
+		try.Header.Set(c.cfg.CorrelationHeader, id)
+	}
+
+	resp, err := c.client.Do(try)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns the wait before retry attempt+1: BaseDelay doubled per
+// attempt and capped at MaxDelay, less a random share of up to half, so
+// callers failing together do not retry together.
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	limit := min(c.cfg.BaseDelay<<attempt, c.cfg.MaxDelay)
+	if limit <= 0 {
+		return 0
+	}
+	return limit/2 + rand.N(limit/2+1)
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at)), true
+	}
+	return 0, false
+}
+
+// cancelOnClose ends an attempt's timeout once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
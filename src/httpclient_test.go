@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testHTTPClient retries quickly, so tests do not wait out real backoffs.
+func testHTTPClient() *HTTPClient {
+	cfg := DefaultHTTPClientConfig()
+	cfg.Timeout = time.Second
+	cfg.BaseDelay = time.Millisecond
+	cfg.MaxDelay = 10 * time.Millisecond
+	return NewHTTPClient(cfg)
+}
+
+func TestHTTPClientRetriesUntilSuccess(t *testing.T) {
+	var (
+		attempts atomic.Int32
+		mu       sync.Mutex
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("PUT", server.URL, strings.NewReader("payload"))
+	resp, err := testHTTPClient().Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 || string(body) != "ok" {
+		t.Errorf("response = %d %q, want 200 ok", resp.StatusCode, body)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("server saw %d attempts, want 3", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, b := range bodies {
+		if b != "payload" {
+			t.Errorf("attempt %d sent body %q, want the whole payload", i+1, b)
+		}
+	}
+}
+
+func TestHTTPClientGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := testHTTPClient().Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || attempts.Load() != 3 {
+		t.Errorf("got %d after %d attempts, want the last 502 after 3", resp.StatusCode, attempts.Load())
+	}
+}
+
+func TestHTTPClientPerAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			// Hang until the client gives up on this attempt.
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := testHTTPClient()
+	client.cfg.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 || attempts.Load() != 2 {
+		t.Errorf("got %d after %d attempts, want 200 after 2", resp.StatusCode, attempts.Load())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want the hung attempt cut off after about 50ms", elapsed)
+	}
+}
+
+func TestHTTPClientTimeoutCoversBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := testHTTPClient()
+	client.cfg.Timeout = 50 * time.Millisecond
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	start := time.Now()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Error("reading a stalled body succeeded, want the attempt's timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("body read took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestHTTPClientDoesNotRetryPOST(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		key          string
+		wantAttempts int32
+	}{
+		{"without Idempotency-Key", "", 1},
+		{"with Idempotency-Key", "charge-1", 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			req, _ := http.NewRequest("POST", server.URL, strings.NewReader("{}"))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			resp, err := testHTTPClient().Do(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", n, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestHTTPClientLongRetryAfterEndsRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := testHTTPClient().Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts.Load() != 1 {
+		t.Errorf("got %d after %d attempts, want the 503 after 1", resp.StatusCode, attempts.Load())
+	}
+}
+
+func TestHTTPClientForwardsCorrelationID(t *testing.T) {
+	got := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get(DefaultCorrelationHeader)
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), correlationIDKey{}, "corr-1")
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := testHTTPClient().Do(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if id := <-got; id != "corr-1" {
+		t.Errorf("%s = %q, want corr-1", DefaultCorrelationHeader, id)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultLocale is the locale used when DEFAULT_LOCALE is unset and the
+// client's Accept-Language matches no catalog.
+const DefaultLocale = "en"
+
+// MessageCatalog maps a locale, such as "es" or "pt-BR", to its translations
+// keyed by message key: an APIError's code, or the message passed to Error.
+type MessageCatalog map[string]map[string]string
+
+// messages is the catalog LocalizationMiddleware translates from. English
+// needs no entries, since the builders' own messages are English.
+var messages = MessageCatalog{
+	"es": {
+		"bad_request":            "La solicitud no es válida",
+		"unauthorized":           "Se requiere autenticación",
+		"forbidden":              "No tiene acceso a este recurso",
+		"not_found":              "No se encontró el recurso solicitado",
+		"conflict":               "La solicitud entra en conflicto con el estado actual",
+		"internal_error":         "Error interno del servidor",
+		"rate_limited":           "Demasiadas solicitudes",
+		"unsupported_media_type": "Tipo de contenido no admitido",
+		"Not found":              "No encontrado",
+		"Method not allowed":     "Método no permitido",
+		"Request timed out":      "Se agotó el tiempo de espera de la solicitud",
+		"Request body too large": "El cuerpo de la solicitud es demasiado grande",
+		"Validation failed":      "La validación falló",
+	},
+}
+
+// translatedErrors are the errors whose messages the catalog's codes
+// translate. Another APIError with one of these codes, such as a 415 naming
+// the form content type it expected, has a more specific message, which
+// LocalizationMiddleware keeps.
+var translatedErrors = []*APIError{
+	ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrInternal,
+	ErrRateLimited, ErrUnsupportedMediaType, ErrNotAcceptable, ErrCircuitOpen, ErrAtCapacity,
+}
+
+// isDefaultMessage reports whether msg is the English message of the
+// translated error with code.
+func isDefaultMessage(code, msg string) bool {
+	for _, e := range translatedErrors {
+		if e.Code == code && e.Message == msg {
+			return true
+		}
+	}
+	return false
+}
+
+// Translate returns the message for key in locale, falling back to the
+// default locale's message and then to key itself.
+func (c MessageCatalog) Translate(locale, defaultLocale, key string) string {
+	if msg, ok := c[locale][key]; ok {
+		return msg
+	}
+	if msg, ok := c[defaultLocale][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// LocalizationMiddleware translates error messages into the locale the
+// client's Accept-Language header prefers among those in catalog, or
+// defaultLocale. {"code": ..., "message": ...} bodies from ErrorResponse are
+// looked up by code when the message is that code's default, as from
+// ErrNotFound; a specific message, or a code with no translation, is kept.
+// {"error": message} bodies from Error are looked up by the message. Translated responses carry Content-Language.
+func LocalizationMiddleware(catalog MessageCatalog, defaultLocale string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			response, err := next(ctx, request)
+			if err != nil || response.StatusCode < 400 || response.IsBase64Encoded || !isJSON(response.Headers) {
+				return response, err
+			}
+
+			locale := catalog.match(GetHeader(request, "Accept-Language"), defaultLocale)
+			addVary(response.Headers, "Accept-Language")
+			response.Headers["Content-Language"] = locale
+			if locale == defaultLocale && len(catalog[defaultLocale]) == 0 {
+				return response, nil
+			}
+
+			decoder := json.NewDecoder(strings.NewReader(response.Body))
+			decoder.UseNumber()
+			var body map[string]interface{}
+			if derr := decoder.Decode(&body); derr != nil {
+				return response, nil
+			}
+
+			if code, ok := body["code"].(string); ok {
+				if msg, ok := body["message"].(string); ok && isDefaultMessage(code, msg) {
+					if translated := catalog.Translate(locale, defaultLocale, code); translated != code {
+						msg = translated
+					}
+					body["message"] = msg
+				}
+			} else if msg, ok := body["error"].(string); ok {
+				body["error"] = catalog.Translate(locale, defaultLocale, msg)
+			}
+
+			translated, merr := marshalJSON(body)
+			if merr != nil {
+				logger.ErrorContext(ctx, "localizing error response", "error", merr)
+				return response, nil
+			}
+			response.Body = translated
+			return response, nil
+		}
+	}
+}
+
+// match returns the catalog locale an Accept-Language header prefers, in
+// quality order. A tag matches a locale exactly, ignoring case, or by its
+// primary language, so es-MX falls back to es; * matches defaultLocale.
+func (c MessageCatalog) match(acceptLanguage, defaultLocale string) string {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if tag == "*" {
+			return defaultLocale
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		for _, candidate := range []string{tag, primary} {
+			if strings.EqualFold(candidate, defaultLocale) {
+				return defaultLocale
+			}
+			for locale := range c {
+				if strings.EqualFold(candidate, locale) {
+					return locale
+				}
+			}
+		}
+	}
+	return defaultLocale
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language
+// header from highest to lowest quality, keeping header order on ties and
+// dropping tags with q=0 or a malformed quality.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestLocalizationKeepsSpecificMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"sentinel", ErrNotFound, "No se encontró el recurso solicitado"},
+		{"wrapped sentinel", fmt.Errorf("loading order 7: %w", ErrNotFound), "No se encontró el recurso solicitado"},
+		{"specific message", NewAPIError(404, "not_found", "Order 7 was not found"), "Order 7 was not found"},
+		{"untranslated code", NewAPIError(400, "invalid_query", "Query parameter \"limit\" is required"), "Query parameter \"limit\" is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := LocalizationMiddleware(messages, DefaultLocale)(func(context.Context, events.APIGatewayProxyRequest) (Response, error) {
+				return ErrorResponse(tt.err), nil
+			})
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Language": "es"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Message != tt.want {
+				t.Errorf("message = %q, want %q", body.Message, tt.want)
+			}
+		})
+	}
+}
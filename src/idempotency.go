@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultIdempotencyTTL is how long completed responses are replayed when
+// IDEMPOTENCY_TTL is unset.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultIdempotencyLockTimeout is how long an in-progress claim holds its
+// key when IDEMPOTENCY_LOCK_TIMEOUT is unset; it matches the default Lambda
+// timeout, after which the claiming invocation cannot still be running.
+const DefaultIdempotencyLockTimeout = 30 * time.Second
+
+const (
+	idempotencyInProgress = "IN_PROGRESS"
+	idempotencyCompleted  = "COMPLETED"
+)
+
+// IdempotencyStore records responses by Idempotency-Key in a DynamoDB table
+// keyed on the string attribute "id", with "expiresAt" as its TTL attribute.
+// Calls go through breaker, when set, so a failing table is not waited on by
+// every request.
+type IdempotencyStore struct {
+	client  *dynamodb.Client
+	table   string
+	ttl     time.Duration
+	breaker *CircuitBreaker
+	// lockTimeout is how long an in-progress claim holds its key, so a
+	// crashed invocation blocks retries only until it would have timed out.
+	lockTimeout time.Duration
+	// fingerprintFallback keys requests that carry no Idempotency-Key by
+	// their RequestFingerprint.
+	fingerprintFallback bool
+}
+
+// NewIdempotencyStore returns a store backed by table.
+func NewIdempotencyStore(client *dynamodb.Client, table string, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{client: client, table: table, ttl: ttl, lockTimeout: DefaultIdempotencyLockTimeout}
+}
+
+// newIdempotencyStore builds the store configured by cfg, or returns nil when
+// no idempotency table is configured.
+func newIdempotencyStore(ctx context.Context, cfg Config) (*IdempotencyStore, error) {
+	if cfg.IdempotencyTable == "" {
+		return nil, nil
+	}
+
+	client, err := awsClients.DynamoDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := NewIdempotencyStore(client, cfg.IdempotencyTable, cfg.IdempotencyTTL)
+	store.breaker = NewCircuitBreaker("idempotency", cfg.CircuitBreaker)
+	store.fingerprintFallback = cfg.IdempotencyFingerprintFallback
+	store.lockTimeout = cfg.IdempotencyLockTimeout
+	return store, nil
+}
+
+var errIdempotencyKeyExists = errors.New("idempotency key already recorded")
+
+// claim records key as in progress until the lock timeout passes. It returns
+// errIdempotencyKeyExists when an unexpired record for the key is already
+// present.
+func (s *IdempotencyStore) claim(ctx context.Context, key string) error {
+	now := time.Now()
+	var exists bool
+	err := s.breaker.Execute(ctx, func() error {
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"id":        &types.AttributeValueMemberS{Value: key},
+				"status":    &types.AttributeValueMemberS{Value: idempotencyInProgress},
+				"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(s.lockTimeout).Unix(), 10)},
+			},
+			// DynamoDB deletes expired items lazily, so treat them as absent.
+			ConditionExpression: aws.String("attribute_not_exists(id) OR expiresAt < :now"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			},
+		})
+		// A failed condition is an answer from a healthy table, not a failure.
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			exists = true
+			return nil
+		}
+		return err
+	})
+	if exists {
+		return errIdempotencyKeyExists
+	}
+	return err
+}
+
+// lookup returns the stored response for key, or ok=false while the first
+// request for the key is still in progress.
+func (s *IdempotencyStore) lookup(ctx context.Context, key string) (response Response, ok bool, err error) {
+	var out *dynamodb.GetItemOutput
+	err = s.breaker.Execute(ctx, func() (err error) {
+		out, err = s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.table),
+			Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		})
+		return err
+	})
+	if err != nil {
+		return Response{}, false, err
+	}
+
+	status, _ := out.Item["status"].(*types.AttributeValueMemberS)
+	stored, _ := out.Item["response"].(*types.AttributeValueMemberS)
+	if status == nil || status.Value != idempotencyCompleted || stored == nil {
+		return Response{}, false, nil
+	}
+	if err := json.Unmarshal([]byte(stored.Value), &response); err != nil {
+		return Response{}, false, fmt.Errorf("decoding stored response: %w", err)
+	}
+	// The body kind is not part of the encoded response; restore it so the
+	// envelope lays out a replayed Page or FanOutResult as it did the first.
+	if kind, ok := out.Item["bodyKind"].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.Atoi(kind.Value)
+		response.kind = bodyKind(n)
+	}
+	return response, true, nil
+}
+
+// complete stores response as the final result for key, replayed for the
+// store's TTL.
+func (s *IdempotencyStore) complete(ctx context.Context, key string, response Response) error {
+	stored, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("encoding response: %w", err)
+	}
+
+	return s.breaker.Execute(ctx, func() error {
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"id":         &types.AttributeValueMemberS{Value: key},
+				"status":     &types.AttributeValueMemberS{Value: idempotencyCompleted},
+				"statusCode": &types.AttributeValueMemberN{Value: strconv.Itoa(response.StatusCode)},
+				"response":   &types.AttributeValueMemberS{Value: string(stored)},
+				"bodyKind":   &types.AttributeValueMemberN{Value: strconv.Itoa(int(response.kind))},
+				"expiresAt":  &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)},
+			},
+		})
+		return err
+	})
+}
+
+// ping checks that the table is reachable with the store's permissions.
+func (s *IdempotencyStore) ping(ctx context.Context) error {
+	return s.breaker.Execute(ctx, func() error {
+		_, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(s.table),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "health-check"}},
+		})
+		return err
+	})
+}
+
+// release removes the in-progress record for key so the client can retry.
+func (s *IdempotencyStore) release(ctx context.Context, key string) error {
+	return s.breaker.Execute(ctx, func() error {
+		_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+		})
+		return err
+	})
+}
+
+// IdempotencyMiddleware replays the stored response for a repeated
+// Idempotency-Key instead of running the handler again, and answers 409 while
+// the first request with that key is still in flight, for up to the store's
+// lock timeout. Failed (5xx) responses
+// are not stored, so they can be retried. While the store's circuit breaker
+// is open, keyed requests get a 503. Keys are scoped by idempotencyKey, so
+// they never collide across callers or endpoints. GET, HEAD and OPTIONS
+// requests, requests without the header, and a nil store pass straight
+// through, unless the store falls back to fingerprints: then a write without
+// the header is keyed by its RequestFingerprint, so an identical repeat
+// within the TTL is replayed.
+func IdempotencyMiddleware(store *IdempotencyStore) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			if store == nil || slices.Contains([]string{"GET", "HEAD", "OPTIONS"}, strings.ToUpper(request.HTTPMethod)) {
+				return next(ctx, request)
+			}
+			var key string
+			if header := GetHeader(request, "Idempotency-Key"); header != "" {
+				key = idempotencyKey(request, header)
+			} else if store.fingerprintFallback {
+				key = "fingerprint:" + RequestFingerprint(request)
+			}
+			if key == "" {
+				return next(ctx, request)
+			}
+
+			err := store.claim(ctx, key)
+			if errors.Is(err, errIdempotencyKeyExists) {
+				response, ok, err := store.lookup(ctx, key)
+				if errors.Is(err, ErrCircuitOpen) {
+					return ErrorResponse(err), nil
+				}
+				if err != nil {
+					logger.ErrorContext(ctx, "looking up idempotency key", "error", err)
+					return Error(500, "Internal server error"), nil
+				}
+				if !ok {
+					return Error(409, "A request with this Idempotency-Key is already in progress"), nil
+				}
+				return response, nil
+			}
+			if errors.Is(err, ErrCircuitOpen) {
+				return ErrorResponse(err), nil
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "claiming idempotency key", "error", err)
+				return Error(500, "Internal server error"), nil
+			}
+
+			response, err := next(ctx, request)
+			if err != nil || response.StatusCode >= 500 {
+				if rerr := store.release(ctx, key); rerr != nil {
+					logger.ErrorContext(ctx, "releasing idempotency key", "error", rerr)
+				}
+				return response, err
+			}
+			if cerr := store.complete(ctx, key, response); cerr != nil {
+				logger.ErrorContext(ctx, "storing idempotent response", "error", cerr)
+			}
+			return response, nil
+		}
+	}
+}
+
+// idempotencyKey returns the stored key for an Idempotency-Key header value:
+// a digest of the value with the request's method, path and credentials, so
+// one caller cannot replay another's response and a key reused on another
+// endpoint is not mistaken for a repeat.
+func idempotencyKey(request events.APIGatewayProxyRequest, header string) string {
+	h := sha256.New()
+	// Each part ends in a NUL so that adjacent parts cannot run together.
+	for _, part := range []string{
+		strings.ToUpper(request.HTTPMethod),
+		request.Path,
+		GetHeader(request, "Authorization"),
+		GetHeader(request, "X-Api-Key"),
+		header,
+	} {
+		h.Write([]byte(part + "\x00"))
+	}
+	return "key:" + hex.EncodeToString(h.Sum(nil))
+}
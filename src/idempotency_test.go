@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	base := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/orders",
+		Headers:    map[string]string{"Authorization": "Bearer alice"},
+	}
+	key := idempotencyKey(base, "abc")
+
+	same := base
+	same.HTTPMethod = "post"
+	same.Headers = map[string]string{"authorization": "Bearer alice"}
+	if got := idempotencyKey(same, "abc"); got != key {
+		t.Errorf("same request keyed %q, want %q", got, key)
+	}
+
+	differ := map[string]events.APIGatewayProxyRequest{}
+	r := base
+	r.HTTPMethod = "PUT"
+	differ["method"] = r
+	r = base
+	r.Path = "/refunds"
+	differ["path"] = r
+	r = base
+	r.Headers = map[string]string{"Authorization": "Bearer mallory"}
+	differ["caller"] = r
+	r = base
+	r.Headers = map[string]string{"Authorization": "Bearer alice", "X-Api-Key": "k1"}
+	differ["api key"] = r
+	for name, request := range differ {
+		if idempotencyKey(request, "abc") == key {
+			t.Errorf("changing the %s kept the key", name)
+		}
+	}
+	if idempotencyKey(base, "abd") == key {
+		t.Error("changing the header value kept the key")
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsReadsInAnyCase(t *testing.T) {
+	// The store has no client, so claiming a key would panic.
+	h := IdempotencyMiddleware(&IdempotencyStore{})(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return Response{StatusCode: 200}, nil
+	})
+	for _, method := range []string{"GET", "get", "Head", "options"} {
+		request := events.APIGatewayProxyRequest{HTTPMethod: method, Headers: map[string]string{"Idempotency-Key": "abc"}}
+		if response, err := h(context.Background(), request); err != nil || response.StatusCode != 200 {
+			t.Errorf("%s = %d, %v; want it passed through", method, response.StatusCode, err)
+		}
+	}
+}
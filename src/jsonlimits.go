@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONLimits bounds the request bodies that BindJSON, Handle and
+// SchemaMiddleware will decode, so a hostile payload is rejected before it
+// costs more than a scan.
+type JSONLimits struct {
+	// MaxDepth is the deepest nesting of objects and arrays allowed.
+	MaxDepth int
+	// MaxTokens caps the keys, values and delimiters in a body.
+	MaxTokens int
+}
+
+// DefaultJSONLimits allows 32 levels of nesting and 100,000 tokens, well
+// beyond any body this API expects.
+func DefaultJSONLimits() JSONLimits {
+	return JSONLimits{MaxDepth: 32, MaxTokens: 100000}
+}
+
+// jsonLimits applies to every JSON request body. main sets it from
+// JSON_MAX_DEPTH and JSON_MAX_TOKENS.
+var jsonLimits = DefaultJSONLimits()
+
+// jsonScanError describes a body scanJSON rejected, for the client.
+type jsonScanError struct {
+	message string
+}
+
+func (e *jsonScanError) Error() string { return e.message }
+
+// jsonFrame is an object or array being scanned.
+type jsonFrame struct {
+	object bool
+	// keys holds an object's keys so far, and key is the one whose value
+	// comes next, or nil when a key is expected. index counts an array's
+	// values so far.
+	keys  map[string]struct{}
+	key   *string
+	index int
+	// prefix is the JSON Pointer to the container itself.
+	prefix string
+}
+
+// scanJSON walks body token by token, without building any values, and
+// rejects it when it nests deeper than limits.MaxDepth, holds more than
+// limits.MaxTokens tokens, or repeats a key within an object, which
+// encoding/json would otherwise resolve silently by keeping the last.
+// Syntax errors are returned as from json.Decoder.
+func scanJSON(body []byte, limits JSONLimits) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var stack []*jsonFrame
+	for tokens := 1; ; tokens++ {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if tokens > limits.MaxTokens {
+			return &jsonScanError{fmt.Sprintf("JSON body has more than %d tokens", limits.MaxTokens)}
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		// An object key: remember it, rejecting repeats.
+		if top != nil && top.object && top.key == nil {
+			if delim, ok := token.(json.Delim); ok && delim == '}' {
+				stack = stack[:len(stack)-1]
+				finishValue(stack)
+				continue
+			}
+			key := token.(string)
+			if _, seen := top.keys[key]; seen {
+				top.key = &key
+				return &jsonScanError{fmt.Sprintf("Duplicate key at %s", jsonPointer(stack))}
+			}
+			top.keys[key] = struct{}{}
+			top.key = &key
+			continue
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			frame := &jsonFrame{object: token == json.Delim('{'), prefix: jsonPointer(stack)}
+			if frame.object {
+				frame.keys = map[string]struct{}{}
+			}
+			stack = append(stack, frame)
+			if len(stack) > limits.MaxDepth {
+				return &jsonScanError{fmt.Sprintf("JSON nests deeper than %d levels at %s", limits.MaxDepth, frame.prefix)}
+			}
+		case json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			finishValue(stack)
+		default:
+			finishValue(stack)
+		}
+	}
+}
+
+// finishValue records that the innermost container's current value is
+// complete.
+func finishValue(stack []*jsonFrame) {
+	if len(stack) == 0 {
+		return
+	}
+	top := stack[len(stack)-1]
+	if top.object {
+		top.key = nil
+	} else {
+		top.index++
+	}
+}
+
+// jsonPointer returns the JSON Pointer to the value being scanned, such as
+// "/items/3", or "/" at the top level.
+func jsonPointer(stack []*jsonFrame) string {
+	if len(stack) == 0 {
+		return "/"
+	}
+	top := stack[len(stack)-1]
+	prefix := top.prefix
+	if prefix == "/" {
+		prefix = ""
+	}
+	if top.object {
+		if top.key == nil {
+			return top.prefix
+		}
+		return prefix + "/" + jsonPointerEscaper.Replace(*top.key)
+	}
+	return fmt.Sprintf("%s/%d", prefix, top.index)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestScanJSONDepth(t *testing.T) {
+	limits := DefaultJSONLimits()
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth)
+	}
+
+	if err := scanJSON([]byte(nested(limits.MaxDepth)), limits); err != nil {
+		t.Errorf("%d levels rejected: %v", limits.MaxDepth, err)
+	}
+
+	err := scanJSON([]byte(nested(1000)), limits)
+	var scanErr *jsonScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("1000 levels = %v, want a jsonScanError", err)
+	}
+	wantAt := strings.Repeat("/a", limits.MaxDepth)
+	if want := "JSON nests deeper than 32 levels at " + wantAt; scanErr.message != want {
+		t.Errorf("message = %q, want %q", scanErr.message, want)
+	}
+
+	arrays := strings.Repeat("[", 1000) + strings.Repeat("]", 1000)
+	if err := scanJSON([]byte(arrays), limits); !errors.As(err, &scanErr) {
+		t.Errorf("1000 nested arrays = %v, want a jsonScanError", err)
+	}
+}
+
+func TestScanJSONDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"top level", `{"role": "user", "role": "admin"}`, "Duplicate key at /role"},
+		{"nested", `{"user": {"id": 1, "name": "a", "id": 2}}`, "Duplicate key at /user/id"},
+		{"in array element", `{"items": [{"sku": "a"}, {"sku": "b", "sku": "c"}]}`, "Duplicate key at /items/1/sku"},
+		{"escaped pointer", `{"a/b": 1, "a/b": 2}`, "Duplicate key at /a~1b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scanJSON([]byte(tt.body), DefaultJSONLimits())
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("scanJSON = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// The same key in sibling objects is not a repeat.
+	if err := scanJSON([]byte(`{"a": {"id": 1}, "b": {"id": 2}, "c": [{"id": 3}, {"id": 4}]}`), DefaultJSONLimits()); err != nil {
+		t.Errorf("sibling keys rejected: %v", err)
+	}
+}
+
+func TestScanJSONTokens(t *testing.T) {
+	limits := JSONLimits{MaxDepth: 32, MaxTokens: 10}
+	// [ plus eight values plus ] is ten tokens.
+	if err := scanJSON([]byte(`[1,2,3,4,5,6,7,8]`), limits); err != nil {
+		t.Errorf("ten tokens rejected: %v", err)
+	}
+	err := scanJSON([]byte(`[1,2,3,4,5,6,7,8,9]`), limits)
+	if err == nil || err.Error() != "JSON body has more than 10 tokens" {
+		t.Errorf("eleven tokens = %v, want the token limit", err)
+	}
+}
+
+func TestBindJSONRejectsHostileBodies(t *testing.T) {
+	type body struct {
+		Role string `json:"role"`
+	}
+	for name, payload := range map[string]string{
+		"deep":      strings.Repeat(`{"a":`, 1000) + "1" + strings.Repeat("}", 1000),
+		"duplicate": `{"role": "user", "role": "admin"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := BindJSON[body](events.APIGatewayProxyRequest{
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    payload,
+			})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatus != 400 || apiErr.Code != "invalid_json" {
+				t.Errorf("BindJSON = %v, want a 400 invalid_json", err)
+			}
+		})
+	}
+}
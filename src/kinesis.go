@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// KinesisRecordFunc processes the payload of one Kinesis record. data is
+// already base64-decoded; record carries the partition key, sequence number
+// and arrival time.
+type KinesisRecordFunc func(ctx context.Context, data []byte, record events.KinesisEventRecord) error
+
+// KinesisHandler adapts process to a Kinesis-triggered Lambda. Every record
+// is processed, a panicking one included, and failures are aggregated into
+// the returned error, which makes Lambda retry the whole batch. With
+// skipFailed set, failed records are logged and skipped instead, so one
+// poison record cannot block its shard; those records are then lost unless
+// process saves them elsewhere.
+func KinesisHandler(process KinesisRecordFunc, skipFailed bool) func(context.Context, events.KinesisEvent) error {
+	return func(ctx context.Context, event events.KinesisEvent) error {
+		markInvocation()
+		ctx = withRequestIDs(ctx, "")
+
+		var errs []error
+		for _, record := range event.Records {
+			err := processKinesisRecord(ctx, process, record)
+			if err == nil {
+				continue
+			}
+
+			msg := "processing Kinesis record"
+			if skipFailed {
+				msg = "skipping failed Kinesis record"
+			}
+			logger.ErrorContext(ctx, msg,
+				"eventId", record.EventID,
+				"partitionKey", record.Kinesis.PartitionKey,
+				"sequenceNumber", record.Kinesis.SequenceNumber,
+				"error", err,
+			)
+			errs = append(errs, fmt.Errorf("record %s: %w", record.Kinesis.SequenceNumber, err))
+		}
+
+		logger.InfoContext(ctx, "kinesis batch",
+			"records", len(event.Records),
+			"failed", len(errs),
+			"skipped", skipFailed && len(errs) > 0,
+		)
+		if skipFailed {
+			return nil
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func processKinesisRecord(ctx context.Context, process KinesisRecordFunc, record events.KinesisEventRecord) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorContext(ctx, "panic processing Kinesis record",
+				"partitionKey", record.Kinesis.PartitionKey,
+				"sequenceNumber", record.Kinesis.SequenceNumber,
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()),
+			)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	// The runtime has already base64-decoded Data while unmarshaling the
+	// event, since it is a []byte field.
+	return process(ctx, record.Kinesis.Data, record)
+}
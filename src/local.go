@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// DefaultLocalAddr is where the local server listens when LOCAL_ADDR is unset.
+const DefaultLocalAddr = "localhost:8080"
+
+// LocalHandler serves h, a handler as passed to lambda.Start that accepts
+// REST API (payload v1) events, over plain HTTP. Each request is translated
+// into an events.APIGatewayProxyRequest and marshaled through h as the
+// Lambda runtime would, and the response is written back, so curl exercises
+// the same code as a deployed function.
+func LocalHandler(h interface{}) http.Handler {
+	invoke := lambda.NewHandler(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := localEvent(r)
+		if err != nil {
+			http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		out, err := invoke.Invoke(r.Context(), payload)
+		if err != nil {
+			// API Gateway answers a failed invocation with a 502.
+			logger.Error("local invocation failed", "error", err)
+			http.Error(w, `{"message": "Internal server error"}`, http.StatusBadGateway)
+			return
+		}
+		var response events.APIGatewayProxyResponse
+		if err := json.Unmarshal(out, &response); err != nil {
+			http.Error(w, "decoding handler response: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeLocalResponse(w, response)
+	})
+}
+
+// localEvent builds the event API Gateway would send for r. Headers and query
+// parameters fill both the single-value maps, with the last value as API
+// Gateway does, and the multi-value maps. Bodies that are not valid UTF-8
+// are base64-encoded, as for a binary media type.
+func localEvent(r *http.Request) (events.APIGatewayProxyRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Resource:   r.URL.Path,
+		Path:       r.URL.Path,
+		HTTPMethod: r.Method,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:        newUUID(),
+			Stage:            "local",
+			Path:             r.URL.Path,
+			HTTPMethod:       r.Method,
+			Protocol:         r.Proto,
+			DomainName:       r.Host,
+			RequestTimeEpoch: time.Now().UnixMilli(),
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  remoteIP(r.RemoteAddr),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	header := r.Header.Clone()
+	if r.Host != "" {
+		// net/http moves Host out of the header map.
+		header.Set("Host", r.Host)
+	}
+	if len(header) > 0 {
+		event.Headers = make(map[string]string, len(header))
+		event.MultiValueHeaders = make(map[string][]string, len(header))
+		for k, values := range header {
+			event.Headers[k] = values[len(values)-1]
+			event.MultiValueHeaders[k] = values
+		}
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		event.QueryStringParameters = make(map[string]string, len(query))
+		event.MultiValueQueryStringParameters = make(map[string][]string, len(query))
+		for k, values := range query {
+			event.QueryStringParameters[k] = values[len(values)-1]
+			event.MultiValueQueryStringParameters[k] = values
+		}
+	}
+
+	if utf8.Valid(body) {
+		event.Body = string(body)
+	} else {
+		event.Body = base64.StdEncoding.EncodeToString(body)
+		event.IsBase64Encoded = true
+	}
+	return event, nil
+}
+
+// writeLocalResponse writes response as API Gateway would: multi-value
+// headers first, single-value headers replacing any of the same name, and a
+// base64 body decoded back to bytes.
+func writeLocalResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			http.Error(w, "decoding base64 response body: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		body = decoded
+	}
+
+	for k, values := range response.MultiValueHeaders {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	for k, v := range response.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write(body)
+}
+
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// serveLocal serves h on LOCAL_ADDR until interrupted, then finishes
+// in-flight requests and calls onShutdown.
+func serveLocal(h interface{}, onShutdown func()) {
+	addr := os.Getenv("LOCAL_ADDR")
+	if addr == "" {
+		addr = DefaultLocalAddr
+	}
+	server := &http.Server{Addr: addr, Handler: LocalHandler(h), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("serving locally", "addr", "http://"+addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("local server failed", "error", err)
+		os.Exit(1)
+	}
+	onShutdown()
+}
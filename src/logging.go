@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logger writes structured JSON logs to stdout, where CloudWatch picks them
+// up. main replaces it once the configured level is known.
+var logger = newLogger(slog.LevelInfo)
+
+// LoggingConfig controls what LoggingMiddleware records about requests.
+type LoggingConfig struct {
+	// RedactHeaders and RedactFields name the headers and JSON or form body
+	// fields whose values are replaced with *** in logs. Names are matched
+	// case-insensitively.
+	RedactHeaders []string
+	RedactFields  []string
+
+	// BodyLogSampleRate is the fraction of invocations, from 0 to 1, whose
+	// request and response bodies are logged at debug level.
+	BodyLogSampleRate float64
+}
+
+// DefaultLoggingConfig redacts credentials and session tokens and logs no
+// bodies.
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		RedactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Amz-Security-Token"},
+		RedactFields:  []string{"password", "token", "accessToken", "access_token", "refreshToken", "refresh_token", "secret", "clientSecret", "client_secret", "apiKey", "api_key"},
+	}
+}
+
+func newLogger(level slog.Level) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+	})})
+}
+
+// contextHandler adds the invocation's request IDs, its correlation ID, and
+// the authenticating API key's identifier, to every record logged with a
+// context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ids, ok := ctx.Value(requestIDsKey{}).(requestIDs); ok {
+		record.AddAttrs(
+			slog.String("requestId", ids.apiGateway),
+			slog.String("awsRequestId", ids.lambda),
+		)
+	}
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("correlationId", id))
+	}
+	if id := APIKeyIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("apiKeyId", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// parseLogLevel maps debug, info, warn and error, in any case, to slog levels.
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+}
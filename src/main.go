@@ -2,61 +2,253 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-type Response struct {
-	StatusCode int               `json:"statusCode"`
-	Headers    map[string]string `json:"headers"`
-	Body       string            `json:"body"`
+// GreetingQuery holds the query parameters accepted by GET /.
+type GreetingQuery struct {
+	Name string `query:"name" default:"World"`
 }
 
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
-	log.Printf("Received request: %+v", request)
+	requestBody, err := DecodeBody(request)
+	if err != nil {
+		logger.WarnContext(ctx, "decoding request body", "error", err)
+		return Error(400, "Invalid base64 request body"), nil
+	}
+
+	query, err := BindQuery[GreetingQuery](request)
+	if err != nil {
+		return Response{}, err
+	}
 
 	responseBody := map[string]interface{}{
-		"message": "Hello from Go Lambda!",
-		"method":  request.HTTPMethod,
-		"path":    request.Path,
-		"headers": request.Headers,
+		"message":  "Hello from Go Lambda!",
+		"greeting": "Hello, " + query.Name + "!",
+		"method":   request.HTTPMethod,
+		"path":     request.Path,
+		"headers":  request.Headers,
+	}
+
+	if len(request.PathParameters) > 0 {
+		responseBody["pathParams"] = request.PathParameters
 	}
 
-	if request.Body != "" {
-		responseBody["body"] = request.Body
+	if len(requestBody) > 0 {
+		responseBody["body"] = string(requestBody)
 	}
 
 	if len(request.QueryStringParameters) > 0 {
 		responseBody["queryParams"] = request.QueryStringParameters
 	}
 
-	body, err := json.Marshal(responseBody)
+	return JSON(200, responseBody), nil
+}
+
+// MessageRequest is the JSON body accepted by POST /api/{name}.
+type MessageRequest struct {
+	Message string                 `json:"message" validate:"required,max=1024"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+func messageHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	message, err := BindJSON[MessageRequest](request)
+	if err != nil {
+		return Response{}, err
+	}
+	if fieldErrs := Validate(message); len(fieldErrs) > 0 {
+		return ValidationError(fieldErrs), nil
+	}
+
+	reply, err := processMessage(ctx, request.PathParameters["name"], message)
 	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
-		return Response{
-			StatusCode: 500,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-			Body: `{"error": "Internal server error"}`,
-		}, nil
+		return Response{}, err
 	}
+	return JSON(200, reply), nil
+}
 
-	return Response{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Content-Type":                 "application/json",
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(body),
+// processMessage is the business logic behind POST /api/{name}, shared with
+// the asynchronous SQS entry point. message has already been validated.
+func processMessage(ctx context.Context, name string, message MessageRequest) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"message": "Hello from Go Lambda!",
+		"name":    name,
+		"request": message,
 	}, nil
 }
 
+func statusHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	return JSON(200, map[string]interface{}{
+		"status": "ok",
+		"caller": APIKeyIDFromContext(ctx),
+	}), nil
+}
+
+func profileHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	claims, _ := ClaimsFromContext(ctx)
+	subject, _ := claims.GetSubject()
+	return JSON(200, map[string]interface{}{
+		"subject": subject,
+		"claims":  claims,
+	}), nil
+}
+
+// entrypoint adapts the HTTP handler chain to the event source the function
+// is deployed behind. The default serves REST APIs (payload v1); building
+// with an event-source tag, such as -tags httpapi, swaps the adapter.
+var entrypoint = func(h HandlerFunc) interface{} { return restHandler(h) }
+
+// restHandler adapts h to REST APIs, whose events already have the shape h
+// takes, so it only marks the invocation.
+func restHandler(h HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		markInvocation()
+		return h(ctx, request)
+	}
+}
+
+// start runs h until the process exits, calling onShutdown when asked to
+// stop. The default hands h to the Lambda runtime; the local build tag
+// serves it over HTTP instead.
+var start = func(h interface{}, onShutdown func()) {
+	lambda.StartWithOptions(h, lambda.WithEnableSIGTERM(onShutdown))
+}
+
 func main() {
-	lambda.Start(handler)
+	cfg, err := LoadConfig(context.Background())
+	if err != nil {
+		logger.Error("loading configuration", "error", err)
+		os.Exit(1)
+	}
+	logger = newLogger(cfg.LogLevel)
+	presignTTL = cfg.PresignTTL
+	fanOutDeadline = cfg.FanOutDeadline
+	fingerprintConfig = cfg.Fingerprint
+	concurrencyLimiter = NewConcurrencyLimiter(cfg.MaxConcurrency, cfg.RetryAfter)
+	httpClient = NewHTTPClient(cfg.HTTPClient)
+	jsonLimits = cfg.JSONLimits
+
+	secrets, err := newSecretsLoader(context.Background(), cfg)
+	if err != nil {
+		logger.Error("loading secrets", "error", err)
+		os.Exit(1)
+	}
+	apiKeys := newAPIKeySource(cfg.APIKeys, secrets)
+
+	idempotency, err := newIdempotencyStore(context.Background(), cfg)
+	if err != nil {
+		logger.Error("configuring idempotency", "error", err)
+		os.Exit(1)
+	}
+
+	limiter, err := newRateLimiter(context.Background(), cfg)
+	if err != nil {
+		logger.Error("configuring rate limiting", "error", err)
+		os.Exit(1)
+	}
+
+	sqsDedup, err = newSQSDeduplicator(context.Background(), cfg)
+	if err != nil {
+		logger.Error("configuring SQS deduplication", "error", err)
+		os.Exit(1)
+	}
+
+	alerter, err := newAlerter(context.Background(), cfg.Alerts)
+	if err != nil {
+		logger.Error("configuring alerts", "error", err)
+		os.Exit(1)
+	}
+
+	if secrets != nil {
+		RegisterHealthCheck("secrets", secrets.ping)
+	}
+	if idempotency != nil {
+		RegisterHealthCheck("idempotency", idempotency.ping)
+	}
+
+	schemas, err := LoadSchemas(schemaFS, "schemas")
+	if err != nil {
+		logger.Error("loading JSON schemas", "error", err)
+		os.Exit(1)
+	}
+
+	router := NewRouter()
+	router.Handle("GET", "/", handler,
+		WithSummary("Greet the caller"), WithQuery[GreetingQuery]())
+	router.Handle("GET", "/health", healthHandler,
+		WithSummary("Check the function and its dependencies"))
+	router.Handle("POST", "/api/{name}", messageHandler,
+		WithSummary("Send a message"), WithRequestBody[MessageRequest](),
+		WithSchema(schemas.MustGet("message.json")))
+	router.Handle("GET", "/openapi.json", Chain(openAPIHandler(router), ETagMiddleware),
+		WithSummary("Describe this API as an OpenAPI document"))
+
+	var auth *JWTAuthenticator
+	if cfg.JWT.JWKSURL != "" {
+		auth, err = NewJWTAuthenticator(context.Background(), cfg.JWT)
+		if err != nil {
+			logger.Error("configuring JWT authentication", "error", err)
+			os.Exit(1)
+		}
+		router.Handle("GET", "/me", Chain(profileHandler, AuthMiddleware(auth), ETagMiddleware, FieldsMiddleware),
+			WithSummary("Return the caller's token claims"))
+	}
+	if len(apiKeys(context.Background())) > 0 {
+		router.Handle("GET", "/internal/status", Chain(statusHandler, APIKeyMiddleware(apiKeys)),
+			WithSummary("Report status to internal callers"))
+	}
+
+	authorizer = NewAuthorizer(auth, apiKeys)
+
+	metrics := NewMetrics(cfg.MetricsNamespace, os.Stdout)
+	OnShutdown("metrics", metrics.Flush)
+	OnShutdown("aws-connections", closeAWSConnections)
+	OnShutdown("http-connections", httpClient.closeIdleConnections)
+
+	middleware := []Middleware{
+		RequestIDMiddleware,
+		CorrelationIDMiddleware(cfg.CorrelationHeader),
+		FeatureFlagMiddleware(cfg.FeatureFlags, cfg.AllowFlagOverrides),
+		LoggingMiddleware(cfg.Logging),
+		MetricsMiddleware(metrics, cfg.EnableResponseSizeHeader),
+	}
+	if cfg.EnableTracing {
+		middleware = append(middleware, TracingMiddleware)
+	}
+	middleware = append(middleware, CORSMiddleware(cfg.CORS), RetryAfterMiddleware(cfg.RetryAfter))
+	if cfg.EnableCompression {
+		middleware = append(middleware, CompressionMiddleware(cfg.CompressionThreshold))
+	}
+	middleware = append(middleware,
+		// Body rewriting runs outside everything that produces a response,
+		// so 406s, 429s, 413s and 504s are enveloped and negotiated too, and is
+		// finished before compression sees the body. Idempotency replays
+		// the bare JSON, which is enveloped afresh on the way out.
+		PrettyMiddleware,
+		NegotiationMiddleware,
+		EnvelopeMiddleware(cfg.Envelope),
+		LocalizationMiddleware(messages, cfg.DefaultLocale),
+		AcceptableMiddleware,
+		TimeoutMiddleware(DefaultTimeoutMargin),
+		RecoverMiddleware,
+		RateLimitMiddleware(limiter),
+		BodyLimitMiddleware(cfg.MaxBodyBytes),
+		DecompressionMiddleware(cfg.MaxDecompressedBytes),
+		ErrorMappingMiddleware,
+		AlertMiddleware(alerter),
+		IdempotencyMiddleware(idempotency),
+		CacheMiddleware(cfg.Cache),
+	)
+
+	var h interface{} = entrypoint(Chain(router.Dispatch, middleware...))
+	if cfg.EnableWarmup {
+		h = WarmupHandler(cfg.Warmup, h)
+	}
+	start(h, func() {
+		shutdown(cfg.ShutdownTimeout)
+		os.Exit(0)
+	})
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// AllowMethods restricts a handler to methods, for single-purpose functions
+// that serve one handler without a Router. Other methods are answered with a
+// JSON 405 and an Allow header before the handler runs, and an OPTIONS
+// request, unless allowed itself, with a 204 listing them, just as
+// Router.Dispatch does:
+//
+//	entrypoint(Chain(uploadHandler, AllowMethods("PUT", "POST")))
+func AllowMethods(methods ...string) Middleware {
+	allowed := make([]string, 0, len(methods))
+	for _, m := range methods {
+		allowed = appendUnique(allowed, strings.ToUpper(m))
+	}
+	// Clip so appending OPTIONS for a preflight never writes to the shared
+	// backing array.
+	allowed = slices.Clip(allowed)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			method := strings.ToUpper(request.HTTPMethod)
+			if !slices.Contains(allowed, method) {
+				return methodNotAllowed(method, allowed), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAllowMethods(t *testing.T) {
+	ran := false
+	h := Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		ran = true
+		return JSON(200, nil), nil
+	}, AllowMethods("put", "POST", "PUT"))
+
+	tests := []struct {
+		method    string
+		wantCode  int
+		wantAllow string
+		wantRan   bool
+	}{
+		{"PUT", 200, "", true},
+		{"post", 200, "", true},
+		{"GET", 405, "PUT, POST", false},
+		{"DELETE", 405, "PUT, POST", false},
+		{"OPTIONS", 204, "PUT, POST, OPTIONS", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ran = false
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: tt.method})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantCode)
+			}
+			if got := response.Headers["Allow"]; got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if ran != tt.wantRan {
+				t.Errorf("handler ran = %v, want %v", ran, tt.wantRan)
+			}
+			if tt.wantCode == 204 && response.Body != "" {
+				t.Errorf("204 body = %q, want empty", response.Body)
+			}
+		})
+	}
+}
+
+func TestAllowMethodsPermitsOptions(t *testing.T) {
+	h := Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, nil), nil
+	}, AllowMethods("GET", "OPTIONS"))
+	response, _ := h(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "OPTIONS"})
+	if response.StatusCode != 200 {
+		t.Errorf("allowed OPTIONS status = %d, want the handler's 200", response.StatusCode)
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	noop := func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, nil), nil
+	}
+	router := NewRouter()
+	router.Handle("GET", "/orders/{id}", noop)
+	router.Handle("DELETE", "/orders/{id}", noop)
+
+	response, _ := router.Dispatch(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "PATCH", Path: "/orders/1"})
+	if response.StatusCode != 405 || response.Headers["Allow"] != "GET, DELETE" {
+		t.Errorf("PATCH = %d with Allow %q, want 405 with GET, DELETE", response.StatusCode, response.Headers["Allow"])
+	}
+	response, _ = router.Dispatch(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "OPTIONS", Path: "/orders/1"})
+	if response.StatusCode != 204 || response.Headers["Allow"] != "GET, DELETE, OPTIONS" {
+		t.Errorf("OPTIONS = %d with Allow %q, want 204 with GET, DELETE, OPTIONS", response.StatusCode, response.Headers["Allow"])
+	}
+}
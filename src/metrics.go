@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultMetricsNamespace is used when METRICS_NAMESPACE is unset.
+const DefaultMetricsNamespace = "GoLambdaCookbook"
+
+// Metrics writes CloudWatch Embedded Metric Format entries. CloudWatch Logs
+// extracts the metrics from stdout, so no agent or API call is needed.
+type Metrics struct {
+	namespace string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewMetrics returns a Metrics writing EMF entries for namespace to out.
+func NewMetrics(namespace string, out io.Writer) *Metrics {
+	return &Metrics{namespace: namespace, out: out}
+}
+
+type metricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// RecordRequest emits the invocation count, latency and request and
+// response body sizes for one request, dimensioned by method, status code and
+// whether it was a cold start.
+func (m *Metrics) RecordRequest(method string, statusCode int, coldStart bool, latency time.Duration, requestBytes, responseBytes int) {
+	m.emit(
+		map[string]string{
+			"Method":     method,
+			"StatusCode": strconv.Itoa(statusCode),
+			"ColdStart":  strconv.FormatBool(coldStart),
+		},
+		[]metricDefinition{
+			{Name: "Invocations", Unit: "Count"},
+			{Name: "Latency", Unit: "Milliseconds"},
+			{Name: "RequestBytes", Unit: "Bytes"},
+			{Name: "ResponseBytes", Unit: "Bytes"},
+		},
+		map[string]float64{
+			"Invocations":   1,
+			"Latency":       float64(latency.Microseconds()) / 1000,
+			"RequestBytes":  float64(requestBytes),
+			"ResponseBytes": float64(responseBytes),
+		},
+	)
+}
+
+func (m *Metrics) emit(dimensions map[string]string, definitions []metricDefinition, values map[string]float64) {
+	dimensionNames := make([]string, 0, len(dimensions))
+	entry := make(map[string]interface{}, len(dimensions)+len(values)+1)
+	for name, value := range dimensions {
+		dimensionNames = append(dimensionNames, name)
+		entry[name] = value
+	}
+	sort.Strings(dimensionNames)
+	for name, value := range values {
+		entry[name] = value
+	}
+	entry["_aws"] = map[string]interface{}{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{{
+			"Namespace":  m.namespace,
+			"Dimensions": [][]string{dimensionNames},
+			"Metrics":    definitions,
+		}},
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("marshaling metrics", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.out.Write(append(line, '\n')); err != nil {
+		logger.Error("writing metrics", "error", err)
+	}
+}
+
+// Flush writes out any metrics held by a buffered writer, such as a
+// bufio.Writer passed to NewMetrics. Unbuffered writers need no flushing.
+func (m *Metrics) Flush(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// MetricsMiddleware records request metrics once the handler returns. It
+// records from a deferred call so error paths are counted too; a handler
+// error is counted as a 500, which is what API Gateway returns for it. Body
+// sizes are the bytes on the wire: after base64 decoding, and, since it runs
+// outside CompressionMiddleware, after gzip. With sizeHeader set, the response
+// size is also returned in X-Response-Size.
+func MetricsMiddleware(m *Metrics, sizeHeader bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (response Response, err error) {
+			start := time.Now()
+			defer func() {
+				statusCode := response.StatusCode
+				if err != nil || statusCode == 0 {
+					statusCode = 500
+				}
+				m.RecordRequest(request.HTTPMethod, statusCode, IsColdStart(), time.Since(start),
+					bodySize(request.Body, request.IsBase64Encoded), bodySize(response.Body, response.IsBase64Encoded))
+			}()
+
+			response, err = next(ctx, request)
+			if sizeHeader && err == nil {
+				if response.Headers == nil {
+					response.Headers = map[string]string{}
+				}
+				response.Headers["X-Response-Size"] = strconv.Itoa(bodySize(response.Body, response.IsBase64Encoded))
+			}
+			return response, err
+		}
+	}
+}
+
+// bodySize returns the decoded length of a body, computed from the base64
+// length without decoding it.
+func bodySize(body string, isBase64 bool) int {
+	if !isBase64 {
+		return len(body)
+	}
+	return base64.StdEncoding.DecodedLen(len(body)) - strings.Count(body[max(0, len(body)-2):], "=")
+}
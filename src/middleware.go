@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Middleware wraps a HandlerFunc to add cross-cutting behaviour. A middleware
+// may short-circuit by returning a Response without calling next.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain wraps h with mw. The first middleware is the outermost, so it sees the
+// request first and the response last.
+func Chain(h HandlerFunc, mw ...Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// LoggingMiddleware logs one structured line per request once the handler
+// has completed, so the entry carries the status code and latency, and the
+// time spent in each downstream dependency under "downstream". At debug
+// level it also logs each request's headers on arrival and, for the fraction
+// of invocations set by cfg.BodyLogSampleRate, the request and response
+// bodies. Values named in cfg are redacted from all of them; the request and
+// response passed on are never modified.
+func LoggingMiddleware(cfg LoggingConfig) Middleware {
+	redact := newRedactor(cfg)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			start := time.Now()
+			ctx = withDownstreamTimings(ctx)
+			debugEnabled := logger.Enabled(ctx, slog.LevelDebug)
+			withBodies := debugEnabled && rand.Float64() < cfg.BodyLogSampleRate
+			if debugEnabled {
+				logRequest(ctx, redact, request, withBodies)
+			}
+			response, err := next(ctx, request)
+			if withBodies && err == nil {
+				logResponse(ctx, redact, response)
+			}
+
+			attrs := []any{
+				"method", request.HTTPMethod,
+				"path", request.Path,
+				"statusCode", response.StatusCode,
+				"durationMs", time.Since(start).Milliseconds(),
+				"coldStart", IsColdStart(),
+				"fingerprint", RequestFingerprint(request),
+			}
+			if downstream := downstreamMillis(ctx); downstream != nil {
+				attrs = append(attrs, "downstream", downstream)
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "request failed", append(attrs, "error", err)...)
+			} else {
+				logger.InfoContext(ctx, "request completed", attrs...)
+			}
+			return response, err
+		}
+	}
+}
+
+func logRequest(ctx context.Context, redact redactor, request events.APIGatewayProxyRequest, withBody bool) {
+	attrs := []any{
+		"method", request.HTTPMethod,
+		"path", request.Path,
+		"headers", redact.requestHeaders(request),
+	}
+	if body, err := DecodeBody(request); withBody && err == nil && len(body) > 0 {
+		attrs = append(attrs, "body", redact.body(GetHeader(request, "Content-Type"), body))
+	}
+	logger.DebugContext(ctx, "request received", attrs...)
+}
+
+func logResponse(ctx context.Context, redact redactor, response Response) {
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			return
+		}
+		body = decoded
+	}
+	if len(body) == 0 {
+		return
+	}
+	// A compressed body does not parse as JSON and is logged by size only.
+	logger.DebugContext(ctx, "response body",
+		"statusCode", response.StatusCode,
+		"body", redact.body(headerValue(response.Headers, "Content-Type"), body),
+	)
+}
+
+// RecoverMiddleware converts a panic in next into a 500 response so a single
+// bad request cannot fail the invocation. The panic value is logged with the
+// stack where it was raised, even when TimeoutMiddleware ran next on another
+// goroutine, and never returned to the client.
+func RecoverMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (response Response, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				stack := debug.Stack()
+				if p, ok := recovered.(*goroutinePanic); ok {
+					recovered, stack = p.value, p.stack
+				}
+				logger.ErrorContext(ctx, "recovered from panic", "panic", fmt.Sprint(recovered), "stack", string(stack))
+				response, err = Error(500, "Internal server error"), nil
+			}
+		}()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	h := RecoverMiddleware(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		if request.Path == "/panic" {
+			panic("boom")
+		}
+		return JSON(200, map[string]string{"ok": "yes"}), nil
+	})
+
+	response, err := h(context.Background(), events.APIGatewayProxyRequest{Path: "/panic"})
+	if err != nil {
+		t.Fatalf("panicking handler returned error %v", err)
+	}
+	if response.StatusCode != 500 {
+		t.Errorf("panicking handler status = %d, want 500", response.StatusCode)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err = h(context.Background(), events.APIGatewayProxyRequest{Path: "/ok"})
+		if err != nil || response.StatusCode != 200 {
+			t.Errorf("invocation %d after panic = %d, %v, want 200", i+1, response.StatusCode, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	mediaJSON = "application/json"
+	mediaText = "text/plain"
+)
+
+// ErrNotAcceptable is returned when the Accept header rules out every
+// representation the API can produce.
+var ErrNotAcceptable = NewAPIError(http.StatusNotAcceptable, "not_acceptable", "Supported response types are application/json and text/plain")
+
+// NegotiationMiddleware serves JSON responses as flattened plain text when
+// the client's Accept header prefers text/plain. A missing Accept header, or
+// */*, gets JSON. Non-JSON and binary responses pass through. It runs outside
+// EnvelopeMiddleware so the enveloped document is what gets flattened; a
+// header that accepts neither type is answered by AcceptableMiddleware.
+func NegotiationMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		mediaType, _ := negotiate(GetHeader(request, "Accept"))
+		response, err := next(ctx, request)
+		if err != nil || response.IsBase64Encoded || !isJSON(response.Headers) {
+			return response, err
+		}
+		addVary(response.Headers, "Accept")
+		if mediaType != mediaText {
+			return response, nil
+		}
+
+		text, ferr := flattenJSON(response.Body)
+		if ferr != nil {
+			logger.ErrorContext(ctx, "flattening response", "error", ferr)
+			return response, nil
+		}
+		response.Headers["Content-Type"] = "text/plain; charset=utf-8"
+		response.Body = text
+		return response, nil
+	}
+}
+
+// AcceptableMiddleware answers 406 before running the handler when the
+// Accept header rules out both JSON and plain text. It runs inside
+// EnvelopeMiddleware, so the 406 is enveloped like any other error.
+func AcceptableMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		if _, ok := negotiate(GetHeader(request, "Accept")); !ok {
+			return ErrorResponse(ErrNotAcceptable), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// negotiate picks the representation for an Accept header: the supported
+// type with the highest quality, preferring JSON on ties. Each type takes its
+// quality from the most specific range that matches it, so
+// "application/json;q=0, */*" rules JSON out. ok is false when the header
+// accepts neither type.
+func negotiate(accept string) (mediaType string, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return mediaJSON, true
+	}
+
+	type match struct {
+		specificity int
+		q           float64
+	}
+	matches := map[string]match{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		for _, candidate := range []string{mediaJSON, mediaText} {
+			specificity := mediaRangeSpecificity(mediaRange, candidate)
+			if m, seen := matches[candidate]; specificity < 0 || (seen && m.specificity > specificity) {
+				continue
+			}
+			matches[candidate] = match{specificity, q}
+		}
+	}
+
+	jsonQ, textQ := matches[mediaJSON].q, matches[mediaText].q
+	switch {
+	case jsonQ > 0 && jsonQ >= textQ:
+		return mediaJSON, true
+	case textQ > 0:
+		return mediaText, true
+	default:
+		return "", false
+	}
+}
+
+// mediaRangeSpecificity reports how specifically mediaRange names
+// mediaType: 2 for an exact match, 1 for type/*, 0 for */* and -1 for no
+// match.
+func mediaRangeSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	default:
+		return -1
+	}
+}
+
+func isJSON(headers map[string]string) bool {
+	mediaType, _, err := mime.ParseMediaType(headerValue(headers, "Content-Type"))
+	return err == nil && mediaType == mediaJSON
+}
+
+// flattenJSON renders a JSON document as "path: value" lines sorted by path,
+// with nested keys and array indexes joined by dots.
+func flattenJSON(body string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return "", err
+	}
+
+	lines := map[string]string{}
+	flattenValue("", doc, lines)
+	if line, ok := lines[""]; ok && len(lines) == 1 {
+		return line + "\n", nil
+	}
+
+	paths := make([]string, 0, len(lines))
+	for path := range lines {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "%s: %s\n", path, lines[path])
+	}
+	return buf.String(), nil
+}
+
+func flattenValue(path string, v interface{}, lines map[string]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenValue(join(key), child, lines)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenValue(join(strconv.Itoa(i)), child, lines)
+		}
+	case nil:
+		lines[path] = "null"
+	default:
+		lines[path] = fmt.Sprint(v)
+	}
+}
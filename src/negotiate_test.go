@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNotAcceptableIsEnveloped(t *testing.T) {
+	ran := false
+	h := Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		ran = true
+		return JSON(200, map[string]string{"id": "1"}), nil
+	}, NegotiationMiddleware, EnvelopeMiddleware(DefaultEnvelopeConfig()), AcceptableMiddleware)
+
+	response, err := h(context.Background(), events.APIGatewayProxyRequest{
+		Path:    "/orders",
+		Headers: map[string]string{"Accept": "image/png"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran {
+		t.Error("handler ran for an unacceptable request")
+	}
+	if response.StatusCode != 406 {
+		t.Fatalf("status = %d, want 406", response.StatusCode)
+	}
+	var env struct {
+		Data  interface{}       `json:"data"`
+		Error map[string]string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(response.Body), &env); err != nil {
+		t.Fatalf("body %s: %v", response.Body, err)
+	}
+	if env.Data != nil || env.Error["code"] != "not_acceptable" {
+		t.Errorf("body = %s, want an enveloped not_acceptable error", response.Body)
+	}
+}
+
+func TestNegotiationFlattensEnvelope(t *testing.T) {
+	h := Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, map[string]string{"id": "1"}), nil
+	}, NegotiationMiddleware, EnvelopeMiddleware(DefaultEnvelopeConfig()), AcceptableMiddleware)
+
+	response, err := h(context.Background(), events.APIGatewayProxyRequest{
+		Path:    "/orders",
+		Headers: map[string]string{"Accept": "text/plain"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(response.Headers["Content-Type"], "text/plain") || !strings.Contains(response.Body, "data.id: 1\n") {
+		t.Errorf("got %q %q, want the flattened envelope", response.Headers["Content-Type"], response.Body)
+	}
+}
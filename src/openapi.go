@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// The info block of the generated OpenAPI document.
+const (
+	openAPITitle   = "go-lambda-terraform-cookbook"
+	openAPIVersion = "1.0.0"
+)
+
+// routeDoc describes a route for the OpenAPI document.
+type routeDoc struct {
+	summary   string
+	query     reflect.Type
+	body      reflect.Type
+	responses map[int]reflect.Type
+}
+
+// WithSummary sets the route's one-line summary.
+func WithSummary(summary string) RouteOption {
+	return func(rt *route) { rt.doc.summary = summary }
+}
+
+// WithQuery documents the route's query parameters from the `query` and
+// `default` tags of T, the struct the handler passes to BindQuery.
+func WithQuery[T any]() RouteOption {
+	return func(rt *route) { rt.doc.query = reflect.TypeFor[T]() }
+}
+
+// WithRequestBody documents the route's JSON request body as T, the type
+// the handler passes to BindJSON.
+func WithRequestBody[T any]() RouteOption {
+	return func(rt *route) { rt.doc.body = reflect.TypeFor[T]() }
+}
+
+// WithResponse documents a JSON response body of type T for status.
+func WithResponse[T any](status int) RouteOption {
+	return func(rt *route) {
+		if rt.doc.responses == nil {
+			rt.doc.responses = map[int]reflect.Type{}
+		}
+		rt.doc.responses[status] = reflect.TypeFor[T]()
+	}
+}
+
+// OpenAPI 3.0 document types, limited to the parts OpenAPISpec emits.
+type (
+	openAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       openAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+		Components *openAPIComponents                      `json:"components,omitempty"`
+	}
+	openAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+	openAPIComponents struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	}
+	openAPIOperation struct {
+		Summary     string                     `json:"summary,omitempty"`
+		Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]openAPIResponse `json:"responses"`
+	}
+	openAPIParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"`
+		Required bool           `json:"required,omitempty"`
+		Schema   *openAPISchema `json:"schema"`
+	}
+	openAPIRequestBody struct {
+		Required bool                        `json:"required"`
+		Content  map[string]openAPIMediaType `json:"content"`
+	}
+	openAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]openAPIMediaType `json:"content,omitempty"`
+	}
+	openAPIMediaType struct {
+		Schema *openAPISchema `json:"schema"`
+	}
+	openAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Items                *openAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+		Default              interface{}               `json:"default,omitempty"`
+	}
+)
+
+// OpenAPISpec returns an OpenAPI 3.0 document describing every registered
+// route: its path parameters, and the query parameters, request body and
+// responses declared with RouteOptions. Named struct types become shared
+// component schemas, with properties named by their `json` tags and
+// `validate:"required"` fields listed as required.
+func (r *Router) OpenAPISpec() ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: openAPITitle, Version: openAPIVersion},
+		Paths:   map[string]map[string]*openAPIOperation{},
+	}
+	schemas := &schemaBuilder{components: map[string]*openAPISchema{}, names: map[reflect.Type]string{}}
+
+	for _, rt := range r.routes {
+		path := "/" + strings.Join(rt.segments, "/")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(rt.method)] = rt.operation(schemas)
+	}
+
+	if len(schemas.components) > 0 {
+		doc.Components = &openAPIComponents{Schemas: schemas.components}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func (rt route) operation(schemas *schemaBuilder) *openAPIOperation {
+	op := &openAPIOperation{Summary: rt.doc.summary, Responses: map[string]openAPIResponse{}}
+
+	for _, segment := range rt.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name:     segment[1 : len(segment)-1],
+				In:       "path",
+				Required: true,
+				Schema:   &openAPISchema{Type: "string"},
+			})
+		}
+	}
+	if rt.doc.query != nil {
+		op.Parameters = append(op.Parameters, queryParameters(rt.doc.query, schemas)...)
+	}
+
+	if rt.doc.body != nil {
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]openAPIMediaType{"application/json": {Schema: schemas.schema(rt.doc.body)}},
+		}
+	}
+
+	for status, t := range rt.doc.responses {
+		op.Responses[strconv.Itoa(status)] = openAPIResponse{
+			Description: http.StatusText(status),
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: schemas.schema(t)}},
+		}
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = openAPIResponse{Description: "Response"}
+	}
+	return op
+}
+
+// queryParameters mirrors BindQuery: every exported field with a `query`
+// tag is a parameter, required with the "required" option, and its
+// `default` tag converted to the field's type.
+func queryParameters(t reflect.Type, schemas *schemaBuilder) []openAPIParameter {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []openAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("query")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemas.schema(field.Type)
+		if fallback, ok := field.Tag.Lookup("default"); ok {
+			v := reflect.New(field.Type).Elem()
+			if err := setFieldFromStrings(v, []string{fallback}); err == nil {
+				schema.Default = v.Interface()
+			}
+		}
+		params = append(params, openAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: options == "required",
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaBuilder converts Go types to schemas, collecting named structs as
+// components so each is described once and recursive types terminate.
+type schemaBuilder struct {
+	components map[string]*openAPISchema
+	names      map[reflect.Type]string
+}
+
+// schema returns the schema for values of t as encoding/json marshals them.
+// TODO: types with custom MarshalJSON methods, other than time.Time and
+// json.RawMessage, are described by their Go structure.
+func (b *schemaBuilder) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int32, reflect.Uint32, reflect.Int16, reflect.Uint16, reflect.Int8, reflect.Uint8:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string.
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return b.ref(t)
+	default:
+		// Interfaces hold any JSON value.
+		return &openAPISchema{}
+	}
+}
+
+// ref registers the named struct t as a component and returns a reference
+// to it.
+func (b *schemaBuilder) ref(t reflect.Type) *openAPISchema {
+	name, ok := b.names[t]
+	if !ok {
+		name = schemaName(t)
+		// Record the name before descending so recursive types refer back
+		// to it instead of looping.
+		b.names[t] = name
+		b.components[name] = b.structSchema(t)
+	}
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	b.addFields(schema, t)
+	return schema
+}
+
+// addFields adds t's fields to schema, promoting the fields of embedded
+// structs as encoding/json does.
+func (b *schemaBuilder) addFields(schema *openAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = b.schema(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isRequired reports whether field carries a top-level `validate:"required"`.
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+		if rule == "dive" {
+			// Rules after dive apply to elements, not the field itself.
+			return false
+		}
+	}
+	return false
+}
+
+// schemaName turns a Go type name into a component name, dropping package
+// qualifiers from type arguments: Page[main.Item] becomes Page_Item.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, args, ok := strings.Cut(name, "[")
+	if !ok {
+		return name
+	}
+
+	parts := []string{base}
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		if i := strings.LastIndexAny(arg, "./"); i >= 0 {
+			arg = arg[i+1:]
+		}
+		parts = append(parts, strings.Trim(arg, "[]*"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// openAPIHandler serves the document for router at GET /openapi.json. It is
+// generated on the first request, once every route has been registered.
+func openAPIHandler(router *Router) HandlerFunc {
+	spec := sync.OnceValues(router.OpenAPISpec)
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		body, err := spec()
+		if err != nil {
+			return Response{}, err
+		}
+		return Response{StatusCode: 200, Headers: defaultHeaders(), Body: string(body)}, nil
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+type openAPITestQuery struct {
+	Limit  int      `query:"limit" default:"20"`
+	Cursor string   `query:"cursor"`
+	Tags   []string `query:"tag"`
+	Filter string   `query:"filter,required"`
+}
+
+type openAPITestOrder struct {
+	ID       string            `json:"id" validate:"required"`
+	Total    float64           `json:"total"`
+	Placed   time.Time         `json:"placed"`
+	Lines    []openAPITestLine `json:"lines"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Note     *string           `json:"note,omitempty"`
+	Internal string            `json:"-"`
+}
+
+type openAPITestLine struct {
+	SKU string `json:"sku" validate:"required"`
+	Qty int    `json:"qty"`
+}
+
+// TestOpenAPISpecGolden compares OpenAPISpec with testdata/openapi.golden.json.
+// Run go test -run OpenAPISpecGolden -update to accept a deliberate change.
+func TestOpenAPISpecGolden(t *testing.T) {
+	noop := func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, nil), nil
+	}
+	router := NewRouter()
+	router.Handle("GET", "/", noop, WithSummary("Greet the caller"), WithQuery[GreetingQuery]())
+	router.Handle("POST", "/api/{name}", noop, WithSummary("Send a message"), WithRequestBody[MessageRequest]())
+	router.Handle("GET", "/orders", noop,
+		WithSummary("List orders"), WithQuery[openAPITestQuery](), WithResponse[[]openAPITestOrder](200))
+	router.Handle("PUT", "/orders/{id}", noop,
+		WithRequestBody[openAPITestOrder](), WithResponse[openAPITestOrder](200), WithResponse[APIError](404))
+	router.Handle("DELETE", "/orders/{id}/lines/{sku}", noop)
+
+	got, err := router.OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "openapi.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("OpenAPISpec differs from %s; rerun with -update if the change is intended.\ngot:\n%s", golden, got)
+	}
+}
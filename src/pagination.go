@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// DefaultPageLimit is the page size used when a request has no limit.
+	DefaultPageLimit = 20
+	// DefaultMaxPageLimit is a sensible maxLimit for BindPage.
+	DefaultMaxPageLimit = 100
+)
+
+// Page is the JSON envelope for one page of a list response.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func (Page[T]) bodyKind() bodyKind { return pageBody }
+
+// NewPage returns a page of items. A non-empty nextCursor marks that more
+// items follow.
+func NewPage[T any](items []T, nextCursor string) Page[T] {
+	if items == nil {
+		// Encode an empty page as [] rather than null.
+		items = []T{}
+	}
+	return Page[T]{Items: items, NextCursor: nextCursor, HasMore: nextCursor != ""}
+}
+
+// PageRequest holds the pagination parameters of a list request.
+type PageRequest struct {
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+}
+
+// BindPage reads the cursor and limit query parameters. A missing limit
+// defaults to DefaultPageLimit, or maxLimit if that is smaller; a limit
+// outside 1..maxLimit is rejected with a 400 APIError.
+func BindPage(request events.APIGatewayProxyRequest, maxLimit int) (PageRequest, error) {
+	page, err := BindQuery[PageRequest](request)
+	if err != nil {
+		return PageRequest{}, err
+	}
+	if len(QueryValues(request, "limit")) == 0 {
+		page.Limit = min(DefaultPageLimit, maxLimit)
+	}
+	if page.Limit < 1 || page.Limit > maxLimit {
+		return PageRequest{}, NewAPIError(400, "invalid_query", fmt.Sprintf("Query parameter \"limit\" must be between 1 and %d", maxLimit))
+	}
+	return page, nil
+}
+
+// EncodeCursor returns an opaque cursor carrying key, typically the last
+// item's key on the current page.
+func EncodeCursor[K any](key K) (string, error) {
+	raw, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor recovers the key carried by a cursor from EncodeCursor. A
+// cursor that does not decode into K is rejected with a 400 APIError.
+func DecodeCursor[K any](cursor string) (K, error) {
+	var key K
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(raw, &key)
+	}
+	if err != nil {
+		return key, NewAPIError(400, "invalid_cursor", "The cursor is invalid")
+	}
+	return key, nil
+}
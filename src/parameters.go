@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// loadParameters fetches every parameter under path from SSM Parameter Store,
+// decrypting SecureString values, and returns them keyed by the environment
+// variable they stand in for. An empty path returns nil. A path that does not
+// exist, or holds no parameters, is not an error: the defaults apply.
+func loadParameters(ctx context.Context, path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	client, err := awsClients.SSM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fetchParameters(ctx, client, path)
+}
+
+func fetchParameters(ctx context.Context, client *ssm.Client, path string) (map[string]string, error) {
+	prefix := "/" + strings.Trim(path, "/")
+	params := map[string]string{}
+
+	pages := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(prefix),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			logger.WarnContext(ctx, "no configuration parameters found", "path", prefix)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetching parameters under %s: %w", prefix, err)
+		}
+		for _, p := range page.Parameters {
+			params[parameterEnvName(prefix, aws.ToString(p.Name))] = aws.ToString(p.Value)
+		}
+	}
+
+	if len(params) == 0 {
+		logger.WarnContext(ctx, "no configuration parameters found", "path", prefix)
+	}
+	return params, nil
+}
+
+// parameterEnvName maps a parameter name to its environment variable by
+// dropping prefix and upper-casing the rest, with hyphens and nested path
+// separators becoming underscores: /app/prod/rate-limit/rps under /app/prod
+// becomes RATE_LIMIT_RPS.
+func parameterEnvName(prefix, name string) string {
+	name = strings.Trim(strings.TrimPrefix(name, prefix), "/")
+	return strings.ToUpper(strings.NewReplacer("-", "_", "/", "_").Replace(name))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/go-playground/validator/v10"
+)
+
+// BindPath maps the path parameters extracted by the Router into a struct
+// of type T using `path` tags, so /users/{id} fills a field tagged
+// `path:"id"`. Fields may be strings, bools, integers or floats, and may
+// carry `validate` tags to constrain the value further:
+//
+//	type UserPath struct {
+//		ID int64 `path:"id" validate:"gt=0"`
+//	}
+//
+// A parameter that is missing, fails to convert or fails validation is
+// rejected with a 400 APIError naming it, so malformed IDs never reach
+// business logic.
+func BindPath[T any](request events.APIGatewayProxyRequest) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, errors.New("BindPath target must be a struct")
+	}
+	if err := bindPath(request, rv); err != nil {
+		return v, err
+	}
+	return v, validatePath(v)
+}
+
+// bindPath is BindPath assigning into the struct rv, without validation.
+func bindPath(request events.APIGatewayProxyRequest, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, ok := field.Tag.Lookup("path")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		raw, ok := request.PathParameters[name]
+		if !ok || raw == "" {
+			return NewAPIError(400, "invalid_path", fmt.Sprintf("Path parameter %q is required", name))
+		}
+		if err := setFieldFromString(rv.Field(i), raw); err != nil {
+			return NewAPIError(400, "invalid_path", fmt.Sprintf("Path parameter %q: %v", name, err))
+		}
+	}
+	return nil
+}
+
+// validatePath checks the `validate` tags of v's path fields, reporting the
+// first failure by parameter name. v is a struct or a pointer to one.
+func validatePath(v interface{}) error {
+	err := structValidator.Struct(v)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	// v may be a pointer to the struct, as when Handle's Req is one.
+	rt := reflect.TypeOf(v)
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	for _, fe := range validationErrs {
+		field, ok := rt.FieldByName(fe.StructField())
+		if !ok {
+			continue
+		}
+		name, ok := field.Tag.Lookup("path")
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		return NewAPIError(400, "invalid_path", fmt.Sprintf("Path parameter %q %s", name, validationMessage(fe)))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type pathTestOrder struct {
+	ID   int64  `path:"id" validate:"gt=0"`
+	Note string `json:"note"`
+}
+
+func TestBindPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    int64
+		wantErr string
+	}{
+		{"numeric", "42", 42, ""},
+		{"non-numeric", "abc", 0, `Path parameter "id"`},
+		{"fails validation", "0", 0, `Path parameter "id"`},
+		{"missing", "", 0, `Path parameter "id" is required`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayProxyRequest{PathParameters: map[string]string{"id": tt.id}}
+			got, err := BindPath[pathTestOrder](request)
+			if tt.wantErr == "" {
+				if err != nil || got.ID != tt.want {
+					t.Errorf("BindPath = %+v, %v, want ID %d", got, err, tt.want)
+				}
+				return
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatus != 400 || !strings.Contains(apiErr.Message, tt.wantErr) {
+				t.Errorf("BindPath error = %v, want a 400 containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandlePointerRequest(t *testing.T) {
+	h := Chain(Handle(func(ctx context.Context, req *pathTestOrder) (*pathTestOrder, error) {
+		return req, nil
+	}), ErrorMappingMiddleware)
+
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{"valid", "7", 200},
+		{"non-numeric", "seven", 400},
+		{"fails validation", "-1", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := h(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod:     "PUT",
+				Headers:        map[string]string{"Content-Type": "application/json"},
+				Body:           `{"note": "rush"}`,
+				PathParameters: map[string]string{"id": tt.id},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", response.StatusCode, tt.wantCode, response.Body)
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+			var got struct {
+				ID   int64  `json:"ID"`
+				Note string `json:"note"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != 7 || got.Note != "rush" {
+				t.Errorf("bound %+v, want ID 7 and the body's note", got)
+			}
+		})
+	}
+}
+
+func TestValidatePathPointer(t *testing.T) {
+	err := validatePath(&pathTestOrder{ID: 0})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_path" {
+		t.Errorf("validatePath(pointer) = %v, want an invalid_path error", err)
+	}
+}
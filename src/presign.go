@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultPresignTTL is how long presigned URLs stay valid when PRESIGN_TTL
+// is unset.
+const DefaultPresignTTL = 15 * time.Minute
+
+// maxPresignTTL is the longest validity SigV4 allows a presigned URL.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// presignTTL is the validity used when PresignGetObject is passed no TTL.
+// main sets it from PRESIGN_TTL.
+var presignTTL = DefaultPresignTTL
+
+// PresignGetObject returns a URL that downloads s3://bucket/key without
+// credentials until ttl passes, so a handler can link to a private object in
+// its JSON response instead of proxying the bytes through Lambda. A ttl of
+// zero uses the configured default. The URL is signed with the function's
+// own credentials, so its role needs s3:GetObject on the object, and it
+// stops working early if those credentials expire first.
+//
+// Errors do not wrap an APIError, so ErrorResponse maps them to a 500.
+func PresignGetObject(ctx context.Context, bucket, key string, ttl time.Duration) (string, error) {
+	if ttl == 0 {
+		ttl = presignTTL
+	}
+	switch {
+	case bucket == "":
+		return "", errors.New("presigning S3 object: bucket is required")
+	case key == "":
+		return "", errors.New("presigning S3 object: key is required")
+	case ttl < 0 || ttl > maxPresignTTL:
+		return "", fmt.Errorf("presigning s3://%s/%s: ttl %s must be between 1s and %s", bucket, key, ttl, maxPresignTTL)
+	}
+
+	client, err := awsClients.S3(ctx)
+	if err != nil {
+		return "", fmt.Errorf("presigning s3://%s/%s: %w", bucket, key, err)
+	}
+	request, err := s3.NewPresignClient(client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("presigning s3://%s/%s: %w", bucket, key, err)
+	}
+	return request.URL, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// PrettyMiddleware indents JSON responses by two spaces when the request has
+// a true pretty query parameter, such as ?pretty=true or a bare ?pretty, for
+// reading responses from curl. Any other value leaves the body compact. It
+// runs after every middleware that rewrites the body and before compression,
+// so errors and envelopes are indented too.
+func PrettyMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		response, err := next(ctx, request)
+		if err != nil || !wantsPretty(request) || response.IsBase64Encoded || !isJSON(response.Headers) {
+			return response, err
+		}
+
+		var buf bytes.Buffer
+		if ierr := json.Indent(&buf, []byte(response.Body), "", "  "); ierr != nil {
+			logger.WarnContext(ctx, "indenting response", "error", ierr)
+			return response, nil
+		}
+		response.Body = buf.String()
+		return response, nil
+	}
+}
+
+func wantsPretty(request events.APIGatewayProxyRequest) bool {
+	values := QueryValues(request, "pretty")
+	if len(values) == 0 {
+		return false
+	}
+	value := values[len(values)-1]
+	if value == "" {
+		return true
+	}
+	pretty, _ := strconv.ParseBool(value)
+	return pretty
+}
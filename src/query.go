@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// BindQuery maps query string parameters into a struct of type T using
+// `query` tags. A tag of `query:"limit,required"` rejects requests without
+// the parameter, and a `default:"20"` tag supplies the value when it is
+// absent. Fields may be strings, bools, integers or floats, or slices of
+// those to receive every value of a repeated parameter. The returned 400
+// APIError describes the first parameter that is missing or fails to convert.
+func BindQuery[T any](request events.APIGatewayProxyRequest) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, errors.New("BindQuery target must be a struct")
+	}
+	err := bindQuery(request, rv)
+	return v, err
+}
+
+// bindQuery is BindQuery assigning into the struct rv.
+func bindQuery(request events.APIGatewayProxyRequest, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("query")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		values := QueryValues(request, name)
+		if len(values) == 0 {
+			if options == "required" {
+				return NewAPIError(400, "invalid_query", fmt.Sprintf("Query parameter %q is required", name))
+			}
+			fallback, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			values = []string{fallback}
+		}
+
+		if err := setFieldFromStrings(rv.Field(i), values); err != nil {
+			return NewAPIError(400, "invalid_query", fmt.Sprintf("Query parameter %q: %v", name, err))
+		}
+	}
+	return nil
+}
+
+// QueryValues returns every value of the query parameter key, preferring
+// the multi-value map so repeated parameters such as ?tag=a&tag=b are kept.
+func QueryValues(request events.APIGatewayProxyRequest, key string) []string {
+	if values, ok := request.MultiValueQueryStringParameters[key]; ok && len(values) > 0 {
+		return values
+	}
+	if value, ok := request.QueryStringParameters[key]; ok {
+		return []string{value}
+	}
+	return nil
+}
+
+// setFieldFromStrings assigns values to a slice field, or the last value to
+// a scalar field, matching what API Gateway puts in the single-value map.
+func setFieldFromStrings(field reflect.Value, values []string) error {
+	if field.Kind() != reflect.Slice {
+		return setFieldFromString(field, values[len(values)-1])
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, raw := range values {
+		if err := setFieldFromString(slice.Index(i), raw); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setFieldFromString converts raw to the field's kind and assigns it.
+func setFieldFromString(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", raw)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid non-negative integer", raw)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", raw)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
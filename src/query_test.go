@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestBindQueryRepeatedKeys(t *testing.T) {
+	type params struct {
+		Tags  []string `query:"tag"`
+		IDs   []int    `query:"id"`
+		Sort  string   `query:"sort"`
+		Limit int      `query:"limit" default:"20"`
+	}
+	request := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"tag": "b", "id": "3", "sort": "desc"},
+		MultiValueQueryStringParameters: map[string][]string{
+			"tag":  {"a", "b"},
+			"id":   {"1", "2", "3"},
+			"sort": {"asc", "desc"},
+		},
+	}
+
+	got, err := BindQuery[params](request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := params{Tags: []string{"a", "b"}, IDs: []int{1, 2, 3}, Sort: "desc", Limit: 20}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BindQuery = %+v, want %+v", got, want)
+	}
+}
+
+func TestBindQueryRepeatedKeyBadValue(t *testing.T) {
+	type params struct {
+		IDs []int `query:"id"`
+	}
+	request := events.APIGatewayProxyRequest{
+		MultiValueQueryStringParameters: map[string][]string{"id": {"1", "two"}},
+	}
+	if _, err := BindQuery[params](request); err == nil {
+		t.Error("BindQuery accepted a non-numeric repeated id")
+	}
+}
+
+func TestHeaderValuesRepeated(t *testing.T) {
+	request := events.APIGatewayProxyRequest{
+		Headers:           map[string]string{"Accept": "text/html"},
+		MultiValueHeaders: map[string][]string{"accept": {"application/json", "text/html"}},
+	}
+	if got, want := HeaderValues(request, "Accept"), []string{"application/json", "text/html"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HeaderValues = %q, want %q", got, want)
+	}
+
+	request.MultiValueHeaders = nil
+	if got, want := HeaderValues(request, "ACCEPT"), []string{"text/html"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HeaderValues without multi-value headers = %q, want %q", got, want)
+	}
+	if got := HeaderValues(request, "X-Missing"); got != nil {
+		t.Errorf("HeaderValues of a missing header = %q, want nil", got)
+	}
+}
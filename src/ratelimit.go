@@ -0,0 +1,238 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrRateLimited answers a request over its caller's rate limit with a 429.
+var ErrRateLimited = NewAPIError(429, "rate_limited", "Too many requests")
+
+// RateLimitResult is the outcome of taking a token for one request.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token is available when the
+	// request was not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter applies a token bucket per client key.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// tokenBucket is the state shared by both limiter backends.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket for the time elapsed since its last update and
+// then tries to remove one token.
+func (b *tokenBucket) take(now time.Time, rate float64, burst int) RateLimitResult {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return RateLimitResult{Allowed: false, RetryAfter: wait}
+	}
+	b.tokens--
+	return RateLimitResult{Allowed: true, Remaining: int(b.tokens)}
+}
+
+// maxMemoryBuckets bounds the in-memory limiter; beyond it, the least
+// recently used bucket is dropped. A dropped caller starts again with a full
+// bucket, so the cap only needs to exceed the callers active at once.
+const maxMemoryBuckets = 10000
+
+// MemoryRateLimiter keeps buckets in the container's memory. Each container
+// limits independently, so the effective limit scales with concurrency.
+type MemoryRateLimiter struct {
+	rate       float64
+	burst      int
+	maxBuckets int
+
+	mu      sync.Mutex
+	order   *list.List // of *memoryBucket, most recently used first
+	buckets map[string]*list.Element
+}
+
+type memoryBucket struct {
+	key string
+	tokenBucket
+}
+
+// NewMemoryRateLimiter allows rate requests per second with bursts of burst.
+func NewMemoryRateLimiter(rate float64, burst int) *MemoryRateLimiter {
+	return &MemoryRateLimiter{rate: rate, burst: burst, maxBuckets: maxMemoryBuckets, order: list.New(), buckets: map[string]*list.Element{}}
+}
+
+// Allow takes a token from key's bucket.
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string) (RateLimitResult, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.buckets[key]
+	if ok {
+		l.order.MoveToFront(el)
+	} else {
+		el = l.order.PushFront(&memoryBucket{key: key, tokenBucket: tokenBucket{tokens: float64(l.burst), updated: now}})
+		l.buckets[key] = el
+		for l.order.Len() > l.maxBuckets {
+			oldest := l.order.Back()
+			l.order.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*memoryBucket).key)
+		}
+	}
+	return el.Value.(*memoryBucket).take(now, l.rate, l.burst), nil
+}
+
+// DynamoDBRateLimiter keeps buckets in a DynamoDB table keyed on the string
+// attribute "id", so the limit holds across containers. Updates use
+// optimistic locking on the bucket's timestamp.
+type DynamoDBRateLimiter struct {
+	client *dynamodb.Client
+	table  string
+	rate   float64
+	burst  int
+}
+
+// NewDynamoDBRateLimiter allows rate requests per second with bursts of
+// burst, storing buckets in table.
+func NewDynamoDBRateLimiter(client *dynamodb.Client, table string, rate float64, burst int) *DynamoDBRateLimiter {
+	return &DynamoDBRateLimiter{client: client, table: table, rate: rate, burst: burst}
+}
+
+const rateLimitWriteAttempts = 3
+
+// Allow takes a token from key's bucket, retrying when a concurrent request
+// updated the bucket first.
+func (l *DynamoDBRateLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	for attempt := 0; attempt < rateLimitWriteAttempts; attempt++ {
+		out, err := l.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(l.table),
+			Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("reading rate limit bucket: %w", err)
+		}
+
+		now := time.Now()
+		b := tokenBucket{tokens: float64(l.burst), updated: now}
+		var previous string
+		if n, ok := out.Item["updatedAt"].(*types.AttributeValueMemberN); ok {
+			previous = n.Value
+			ms, _ := strconv.ParseInt(n.Value, 10, 64)
+			b.updated = time.UnixMilli(ms)
+			if t, ok := out.Item["tokens"].(*types.AttributeValueMemberN); ok {
+				b.tokens, _ = strconv.ParseFloat(t.Value, 64)
+			}
+		}
+		result := b.take(now, l.rate, l.burst)
+
+		input := &dynamodb.PutItemInput{
+			TableName: aws.String(l.table),
+			Item: map[string]types.AttributeValue{
+				"id":        &types.AttributeValueMemberS{Value: key},
+				"tokens":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(b.tokens, 'f', -1, 64)},
+				"updatedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.UnixMilli(), 10)},
+				"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(time.Hour).Unix(), 10)},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		}
+		if previous != "" {
+			input.ConditionExpression = aws.String("updatedAt = :previous")
+			input.ExpressionAttributeValues = map[string]types.AttributeValue{
+				":previous": &types.AttributeValueMemberN{Value: previous},
+			}
+		}
+
+		_, err = l.client.PutItem(ctx, input)
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			continue
+		}
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("writing rate limit bucket: %w", err)
+		}
+		return result, nil
+	}
+	return RateLimitResult{}, errors.New("rate limit bucket contended")
+}
+
+// newRateLimiter builds the limiter selected by cfg, or returns nil when rate
+// limiting is disabled.
+func newRateLimiter(ctx context.Context, cfg Config) (RateLimiter, error) {
+	if cfg.RateLimitRate <= 0 {
+		return nil, nil
+	}
+	if cfg.RateLimitBackend != "dynamodb" {
+		return NewMemoryRateLimiter(cfg.RateLimitRate, cfg.RateLimitBurst), nil
+	}
+
+	client, err := awsClients.DynamoDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewDynamoDBRateLimiter(client, cfg.RateLimitTable, cfg.RateLimitRate, cfg.RateLimitBurst), nil
+}
+
+// rateLimitKey identifies the caller by source IP. The limiter runs before
+// any credentials are checked, so a presented API key or token cannot be
+// trusted to name the caller: keying on it would let a client claim a fresh
+// bucket per request by inventing keys.
+func rateLimitKey(request events.APIGatewayProxyRequest) string {
+	return "ip:" + request.RequestContext.Identity.SourceIP
+}
+
+// RateLimitMiddleware rejects callers that have exhausted their bucket with
+// a 429 and a Retry-After header. Allowed responses carry the remaining
+// quota in X-RateLimit-Remaining. If the limiter itself fails the request is
+// let through rather than turning a limiter outage into an API outage. A nil
+// limiter disables the middleware.
+func RateLimitMiddleware(limiter RateLimiter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			if limiter == nil {
+				return next(ctx, request)
+			}
+
+			result, err := limiter.Allow(ctx, rateLimitKey(request))
+			if err != nil {
+				logger.ErrorContext(ctx, "checking rate limit", "error", err)
+				return next(ctx, request)
+			}
+
+			if !result.Allowed {
+				response := ErrorResponse(ErrRateLimited)
+				setRetryAfter(response.Headers, result.RetryAfter)
+				response.Headers["X-RateLimit-Remaining"] = "0"
+				return response, nil
+			}
+
+			response, err := next(ctx, request)
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			response.Headers["X-RateLimit-Remaining"] = strconv.Itoa(result.Remaining)
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRateLimitKeyIgnoresAPIKey(t *testing.T) {
+	request := func(apiKey string) events.APIGatewayProxyRequest {
+		r := events.APIGatewayProxyRequest{Headers: map[string]string{"X-Api-Key": apiKey}}
+		r.RequestContext.Identity.SourceIP = "203.0.113.7"
+		return r
+	}
+	if a, b := rateLimitKey(request("one")), rateLimitKey(request("two")); a != b {
+		t.Errorf("keys for one IP with different API keys differ: %q, %q", a, b)
+	}
+}
+
+func TestMemoryRateLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryRateLimiter(0.001, 1)
+	l.maxBuckets = 3
+
+	for _, key := range []string{"a", "b", "c"} {
+		if result, _ := l.Allow(ctx, key); !result.Allowed {
+			t.Fatalf("first request for %s refused", key)
+		}
+	}
+	// Touch a so that b is the least recently used when d arrives.
+	if result, _ := l.Allow(ctx, "a"); result.Allowed {
+		t.Fatal("second request for a allowed with an empty bucket")
+	}
+	l.Allow(ctx, "d")
+
+	if len(l.buckets) != 3 || l.order.Len() != 3 {
+		t.Fatalf("holding %d buckets (%d in order), want 3", len(l.buckets), l.order.Len())
+	}
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("b was kept, want it evicted")
+	}
+	if result, _ := l.Allow(ctx, "a"); result.Allowed {
+		t.Error("a was evicted and refilled, want it kept")
+	}
+}
+
+func TestMemoryRateLimiterStaysBounded(t *testing.T) {
+	l := NewMemoryRateLimiter(1, 1)
+	for i := 0; i < maxMemoryBuckets+100; i++ {
+		l.Allow(context.Background(), fmt.Sprintf("ip:%d", i))
+	}
+	if len(l.buckets) != maxMemoryBuckets {
+		t.Errorf("holding %d buckets, want %d", len(l.buckets), maxMemoryBuckets)
+	}
+}
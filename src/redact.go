@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const redacted = "***"
+
+// redactor applies a LoggingConfig's redaction lists. It never modifies the
+// values it is given; redacted copies are returned instead.
+type redactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+}
+
+func newRedactor(cfg LoggingConfig) redactor {
+	r := redactor{headers: map[string]bool{}, fields: map[string]bool{}}
+	for _, name := range cfg.RedactHeaders {
+		r.headers[strings.ToLower(name)] = true
+	}
+	for _, name := range cfg.RedactFields {
+		r.fields[strings.ToLower(name)] = true
+	}
+	return r
+}
+
+// requestHeaders returns request's headers with sensitive values redacted,
+// merging multi-value headers into comma-separated values.
+func (r redactor) requestHeaders(request events.APIGatewayProxyRequest) map[string]string {
+	headers := make(map[string]string, len(request.Headers)+len(request.MultiValueHeaders))
+	for name, values := range request.MultiValueHeaders {
+		headers[name] = strings.Join(values, ",")
+	}
+	for name, value := range request.Headers {
+		headers[name] = value
+	}
+	for name := range headers {
+		if r.headers[strings.ToLower(name)] {
+			headers[name] = redacted
+		}
+	}
+	return headers
+}
+
+// body returns a loggable copy of body. JSON and form bodies have sensitive
+// fields redacted, with form fields logged as an object; any other body is
+// summarized by size, since it cannot be inspected for secrets.
+func (r redactor) body(contentType string, body []byte) interface{} {
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		fields := make(map[string]string, len(values))
+		for name, v := range values {
+			fields[name] = strings.Join(v, ",")
+			if r.fields[strings.ToLower(name)] {
+				fields[name] = redacted
+			}
+		}
+		return fields
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			break
+		}
+		out, err := json.Marshal(r.value(doc))
+		if err != nil {
+			break
+		}
+		return json.RawMessage(out)
+	}
+	return "[" + strconv.Itoa(len(body)) + " bytes]"
+}
+
+func (r redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if r.fields[strings.ToLower(key)] {
+				out[key] = redacted
+				continue
+			}
+			out[key] = r.value(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = r.value(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrUnsupportedMediaType is returned by BindJSON when the request is not
+// declared as application/json.
+var ErrUnsupportedMediaType = NewAPIError(415, "unsupported_media_type", "Content type must be application/json")
+
+// DecodeBody returns the raw request body, base64-decoding it when API
+// Gateway has flagged the payload as binary.
+func DecodeBody(request events.APIGatewayProxyRequest) ([]byte, error) {
+	if !request.IsBase64Encoded {
+		return []byte(request.Body), nil
+	}
+
+	body, err := base64.StdEncoding.DecodeString(request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 body: %w", err)
+	}
+	return body, nil
+}
+
+// BindJSON unmarshals the (possibly base64-encoded) JSON request body into a
+// value of type T. It returns ErrUnsupportedMediaType when the Content-Type is
+// not application/json, and a 400 APIError describing the problem when the
+// JSON is malformed, nests too deeply, has too many tokens or repeats a key
+// within an object.
+func BindJSON[T any](request events.APIGatewayProxyRequest) (T, error) {
+	var v T
+	err := bindJSON(request, &v, false)
+	return v, err
+}
+
+// bindJSON is BindJSON decoding into an existing value, so fields already
+// set, such as by BindQuery, survive when the body omits them. With
+// disallowUnknown, a key matching no field of a struct is a 400 too.
+func bindJSON(request events.APIGatewayProxyRequest, v interface{}, disallowUnknown bool) error {
+	mediaType, _, _ := mime.ParseMediaType(GetHeader(request, "Content-Type"))
+	if mediaType != "application/json" {
+		return ErrUnsupportedMediaType
+	}
+
+	body, err := DecodeBody(request)
+	if err != nil {
+		return NewAPIError(400, "invalid_body", "Invalid base64 request body")
+	}
+	if len(body) == 0 {
+		return NewAPIError(400, "invalid_json", "Request body is empty")
+	}
+
+	if err := decodeJSON(body, v, disallowUnknown); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var scanErr *jsonScanError
+		switch {
+		case errors.As(err, &scanErr):
+			return NewAPIError(400, "invalid_json", scanErr.message)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return NewAPIError(400, "invalid_json", "Malformed JSON: unexpected end of input")
+		case errors.As(err, &syntaxErr):
+			return NewAPIError(400, "invalid_json", fmt.Sprintf("Malformed JSON at offset %d: %v", syntaxErr.Offset, err))
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return NewAPIError(400, "invalid_json", fmt.Sprintf("Field %q must be %s", typeErr.Field, typeErr.Type))
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return NewAPIError(400, "invalid_json", "Unknown field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+		default:
+			return NewAPIError(400, "invalid_json", fmt.Sprintf("Invalid JSON body: %v", err))
+		}
+	}
+	return nil
+}
+
+// decodeJSON checks body against jsonLimits, then decodes it into v as
+// json.Unmarshal would, rejecting anything after the value.
+func decodeJSON(body []byte, v interface{}, disallowUnknown bool) error {
+	if err := scanJSON(body, jsonLimits); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// GetHeader returns the value of the named request header. Header names are
+// case-insensitive, and clients and API Gateway payload versions disagree on
+// case, so the name is matched case-insensitively across both the
+// single-value and multi-value maps; of a repeated header, the last value is
+// returned, as in the single-value map.
+func GetHeader(request events.APIGatewayProxyRequest, name string) string {
+	if v, ok := lookupHeader(request.Headers, name); ok {
+		return v
+	}
+	if values, ok := lookupHeader(request.MultiValueHeaders, name); ok && len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+// HeaderValues returns every value of the named request header, preferring
+// the multi-value map so repeated headers are kept, and matching the name
+// case-insensitively.
+func HeaderValues(request events.APIGatewayProxyRequest, name string) []string {
+	if values, ok := lookupHeader(request.MultiValueHeaders, name); ok && len(values) > 0 {
+		return values
+	}
+	if v, ok := lookupHeader(request.Headers, name); ok && v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// headerValue returns the named header from a single-value map, matching
+// the name case-insensitively.
+func headerValue(headers map[string]string, name string) string {
+	v, _ := lookupHeader(headers, name)
+	return v
+}
+
+// lookupHeader finds name in headers whatever the case of either. The
+// canonical form, such as Content-Type, wins, then name as given; when a map
+// holds only other variants, such as content-type and CONTENT-TYPE, the
+// first in sorted order is used so the result does not depend on map order.
+func lookupHeader[V any](headers map[string]V, name string) (V, bool) {
+	canonical := textproto.CanonicalMIMEHeaderKey(name)
+	if v, ok := headers[canonical]; ok {
+		return v, true
+	}
+	if v, ok := headers[name]; ok {
+		return v, true
+	}
+
+	var (
+		match string
+		value V
+		found bool
+	)
+	for k, v := range headers {
+		if strings.EqualFold(k, canonical) && (!found || k < match) {
+			match, value, found = k, v, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestGetHeaderMixedCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayProxyRequest
+		lookup  string
+		want    string
+	}{
+		{
+			name:    "lower-case key",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"content-type": "application/json"}},
+			lookup:  "Content-Type",
+			want:    "application/json",
+		},
+		{
+			name:    "upper-case lookup",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"X-Api-Key": "k1"}},
+			lookup:  "X-API-KEY",
+			want:    "k1",
+		},
+		{
+			name:    "odd-case key and lookup",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"x-REQUEST-id": "r1"}},
+			lookup:  "X-Request-Id",
+			want:    "r1",
+		},
+		{
+			name: "canonical key wins",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{
+				"content-type": "text/plain",
+				"Content-Type": "application/json",
+				"CONTENT-TYPE": "text/html",
+			}},
+			lookup: "content-type",
+			want:   "application/json",
+		},
+		{
+			name: "variants resolve in sorted order",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{
+				"content-type": "text/plain",
+				"CONTENT-TYPE": "text/html",
+			}},
+			lookup: "Content-Type",
+			want:   "text/html",
+		},
+		{
+			name:    "multi-value only",
+			request: events.APIGatewayProxyRequest{MultiValueHeaders: map[string][]string{"accept-LANGUAGE": {"fr", "en"}}},
+			lookup:  "Accept-Language",
+			want:    "en",
+		},
+		{
+			name:    "missing",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"Accept": "*/*"}},
+			lookup:  "Authorization",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map order varies between runs, so look up repeatedly.
+			for i := 0; i < 20; i++ {
+				if got := GetHeader(tt.request, tt.lookup); got != tt.want {
+					t.Fatalf("GetHeader(%q) = %q, want %q", tt.lookup, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+type requestIDsKey struct{}
+
+type requestIDs struct {
+	apiGateway string
+	lambda     string
+}
+
+// RequestIDMiddleware records the API Gateway and Lambda request IDs in the
+// context so every log line for the invocation can be correlated.
+func RequestIDMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return next(withRequestIDs(ctx, request.RequestContext.RequestID), request)
+	}
+}
+
+// withRequestIDs records the event's own request ID, if it has one, alongside
+// the Lambda request ID.
+func withRequestIDs(ctx context.Context, eventID string) context.Context {
+	ids := requestIDs{apiGateway: eventID}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		ids.lambda = lc.AwsRequestID
+	}
+	return context.WithValue(ctx, requestIDsKey{}, ids)
+}
+
+// RequestIDFromContext returns the API Gateway request ID for the current
+// invocation, falling back to the Lambda request ID when there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	ids, _ := ctx.Value(requestIDsKey{}).(requestIDs)
+	if ids.apiGateway != "" {
+		return ids.apiGateway
+	}
+	return ids.lambda
+}
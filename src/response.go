@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Response is the API Gateway proxy integration response.
+type Response struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	// Cookies holds one Set-Cookie value per cookie, since Headers can only
+	// hold one. Each event-source adapter sends them the way its payload
+	// format expects; use AddCookie to fill it.
+	Cookies []string `json:"-"`
+
+	// kind marks a body that JSON built from a Page or FanOutResult, which
+	// the envelope and FieldsMiddleware rearrange.
+	kind bodyKind
+}
+
+// AddCookie appends c to the response's cookies. An invalid cookie, such as
+// one whose name has a space, is logged and dropped.
+func (r *Response) AddCookie(c http.Cookie) {
+	if err := c.Valid(); err != nil {
+		logger.Warn("dropping invalid cookie", "name", c.Name, "error", err)
+		return
+	}
+	r.Cookies = append(r.Cookies, c.String())
+}
+
+// plainResponse is Response without its JSON methods.
+type plainResponse Response
+
+// MarshalJSON encodes the REST API (payload v1) proxy response, the format
+// returned to Lambda by default. Cookies are sent as
+// multiValueHeaders["Set-Cookie"], which API Gateway merges with headers.
+func (r Response) MarshalJSON() ([]byte, error) {
+	out := struct {
+		plainResponse
+		MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	}{plainResponse: plainResponse(r)}
+	if len(r.Cookies) > 0 {
+		out.MultiValueHeaders = map[string][]string{"Set-Cookie": r.Cookies}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes what MarshalJSON encodes, so stored responses keep
+// their cookies.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var in struct {
+		plainResponse
+		MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = Response(in.plainResponse)
+	r.Cookies = in.MultiValueHeaders["Set-Cookie"]
+	return nil
+}
+
+// responseCookies returns every cookie the response sets, from Cookies and
+// from any Set-Cookie entry in Headers, along with the headers minus those
+// entries, for payload formats that carry cookies apart from headers.
+func responseCookies(response Response) (cookies []string, headers map[string]string) {
+	headers = response.Headers
+	for k, v := range response.Headers {
+		if strings.EqualFold(k, "Set-Cookie") {
+			cookies = append(cookies, v)
+		}
+	}
+	if len(cookies) > 0 {
+		headers = withoutHeaders(response.Headers, "Set-Cookie")
+	}
+	return append(cookies, response.Cookies...), headers
+}
+
+const internalErrorBody = `{"error": "Internal server error"}`
+
+// maxPooledBuffer caps the buffers kept for reuse, so one very large
+// response does not pin its memory for the container's lifetime.
+const maxPooledBuffer = 64 << 10
+
+// jsonBuffer pairs a buffer with an encoder writing to it, so neither is
+// allocated per response.
+type jsonBuffer struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := &jsonBuffer{}
+		b.enc = json.NewEncoder(&b.buf)
+		return b
+	},
+}
+
+// marshalJSON encodes payload like json.Marshal, using a pooled buffer. The
+// buffer goes back to the pool whether or not encoding succeeds.
+func marshalJSON(payload interface{}) (string, error) {
+	b := jsonBufferPool.Get().(*jsonBuffer)
+	b.buf.Reset()
+	defer func() {
+		if b.buf.Cap() <= maxPooledBuffer {
+			jsonBufferPool.Put(b)
+		}
+	}()
+
+	if err := b.enc.Encode(payload); err != nil {
+		return "", err
+	}
+	// Encode terminates the value with a newline that Marshal does not.
+	return string(bytes.TrimSuffix(b.buf.Bytes(), []byte("\n"))), nil
+}
+
+// JSON builds a response with payload marshaled as the JSON body. A payload
+// that cannot be marshaled yields a 500 with a fixed error body.
+func JSON(statusCode int, payload interface{}) Response {
+	body, err := marshalJSON(payload)
+	if err != nil {
+		logger.Error("marshaling response", "error", err)
+		return Response{
+			StatusCode: 500,
+			Headers:    defaultHeaders(),
+			Body:       internalErrorBody,
+		}
+	}
+
+	response := Response{
+		StatusCode: statusCode,
+		Headers:    defaultHeaders(),
+		Body:       body,
+	}
+	if k, ok := payload.(kindedBody); ok {
+		response.kind = k.bodyKind()
+	}
+	return response
+}
+
+// Binary builds a response carrying data, such as an image or PDF, as a
+// base64-encoded body with the given Content-Type. REST APIs only decode it
+// back to bytes when contentType, or the client's Accept header, matches one
+// of the API's binary media types.
+func Binary(statusCode int, contentType string, data []byte) Response {
+	return Response{
+		StatusCode:      statusCode,
+		Headers:         map[string]string{"Content-Type": contentType},
+		Body:            base64.StdEncoding.EncodeToString(data),
+		IsBase64Encoded: true,
+	}
+}
+
+// Error builds a JSON error response of the form {"error": message}.
+func Error(statusCode int, message string) Response {
+	return JSON(statusCode, map[string]string{"error": message})
+}
+
+// addVary appends value to the Vary header without dropping earlier entries.
+func addVary(headers map[string]string, value string) {
+	existing := headers["Vary"]
+	for _, v := range strings.Split(existing, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return
+		}
+	}
+	if existing == "" {
+		headers["Vary"] = value
+		return
+	}
+	headers["Vary"] = existing + ", " + value
+}
+
+func defaultHeaders() map[string]string {
+	return map[string]string{
+		"Content-Type": "application/json",
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	// Every byte value, including NUL and invalid UTF-8, must survive.
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	response := Binary(200, "application/octet-stream", data)
+
+	if !response.IsBase64Encoded {
+		t.Error("IsBase64Encoded = false")
+	}
+	if got := response.Headers["Content-Type"]; got != "application/octet-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Errorf("decoded body = %v, want %v", decoded, data)
+	}
+
+	// The body is already encoded, so compression and the adapters must
+	// pass it through untouched.
+	h := CompressionMiddleware(1)(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return response, nil
+	})
+	compressed, _ := h(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+	if compressed.Body != response.Body || compressed.Headers["Content-Encoding"] != "" {
+		t.Error("compression re-encoded a binary body")
+	}
+	if url := toFunctionURLResponse(response); !url.IsBase64Encoded || url.Body != response.Body {
+		t.Errorf("Function URL response = %+v, want the same base64 body", url)
+	}
+	if alb := toALBResponse(response, false); !alb.IsBase64Encoded || alb.Body != response.Body {
+		t.Errorf("ALB response = %+v, want the same base64 body", alb)
+	}
+}
+
+// benchmarkPayload is about the size of a typical list response.
+var benchmarkPayload = func() map[string]interface{} {
+	items := make([]map[string]interface{}, 50)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "name": "item", "tags": []string{"a", "b"}, "price": 9.99}
+	}
+	return map[string]interface{}{"items": items, "nextCursor": "abc123"}
+}()
+
+func TestMarshalJSONMatchesMarshal(t *testing.T) {
+	want, err := json.Marshal(benchmarkPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Twice, so the second call reuses a pooled buffer.
+	for i := 0; i < 2; i++ {
+		got, err := marshalJSON(benchmarkPayload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("marshalJSON = %s, want %s", got, want)
+		}
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := marshalJSON(benchmarkPayload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkJSONMarshal is the unpooled baseline for BenchmarkMarshalJSON.
+func BenchmarkJSONMarshal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body, err := json.Marshal(benchmarkPayload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = string(body)
+	}
+}
+
+func twoCookieHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	response := JSON(200, nil)
+	response.AddCookie(http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+	response.AddCookie(http.Cookie{Name: "theme", Value: "dark"})
+	return response, nil
+}
+
+var twoCookies = []string{"session=abc; Path=/; HttpOnly", "theme=dark"}
+
+func TestTwoCookiesRESTAPI(t *testing.T) {
+	response, _ := twoCookieHandler(context.Background(), events.APIGatewayProxyRequest{})
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proxy events.APIGatewayProxyResponse
+	if err := json.Unmarshal(body, &proxy); err != nil {
+		t.Fatal(err)
+	}
+	if got := proxy.MultiValueHeaders["Set-Cookie"]; !reflect.DeepEqual(got, twoCookies) {
+		t.Errorf("multiValueHeaders Set-Cookie = %q, want %q", got, twoCookies)
+	}
+
+	var stored Response
+	if err := json.Unmarshal(body, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored.Cookies, twoCookies) {
+		t.Errorf("cookies after a round trip = %q, want %q", stored.Cookies, twoCookies)
+	}
+}
+
+func TestTwoCookiesHTTPAPI(t *testing.T) {
+	response, _ := HTTPAPIHandler(twoCookieHandler)(context.Background(), events.APIGatewayV2HTTPRequest{})
+	if !reflect.DeepEqual(response.Cookies, twoCookies) {
+		t.Errorf("cookies = %q, want %q", response.Cookies, twoCookies)
+	}
+	if v := headerValue(response.Headers, "Set-Cookie"); v != "" {
+		t.Errorf("Set-Cookie header = %q, want cookies only in Cookies", v)
+	}
+}
+
+func TestTwoCookiesFunctionURL(t *testing.T) {
+	response, _ := FunctionURLHandler(twoCookieHandler)(context.Background(), events.LambdaFunctionURLRequest{})
+	if !reflect.DeepEqual(response.Cookies, twoCookies) {
+		t.Errorf("cookies = %q, want %q", response.Cookies, twoCookies)
+	}
+}
+
+func TestTwoCookiesALB(t *testing.T) {
+	multi, _ := ALBHandler(twoCookieHandler)(context.Background(), events.ALBTargetGroupRequest{
+		MultiValueHeaders: map[string][]string{"accept": {"*/*"}},
+	})
+	if got := multi.MultiValueHeaders["Set-Cookie"]; !reflect.DeepEqual(got, twoCookies) {
+		t.Errorf("multi-value Set-Cookie = %q, want %q", got, twoCookies)
+	}
+
+	// Without multi-value headers only one cookie fits; the last wins.
+	single, _ := ALBHandler(twoCookieHandler)(context.Background(), events.ALBTargetGroupRequest{
+		Headers: map[string]string{"accept": "*/*"},
+	})
+	if got := single.Headers["Set-Cookie"]; got != twoCookies[1] {
+		t.Errorf("single-value Set-Cookie = %q, want %q", got, twoCookies[1])
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RetryAfterConfig sets the Retry-After hint sent with 429 and 503 responses
+// that do not carry their own.
+type RetryAfterConfig struct {
+	// Base is the minimum delay suggested to the client.
+	Base time.Duration
+	// Jitter is the upper bound of a random delay added to Base, so clients
+	// turned away together do not all retry in the same second.
+	Jitter time.Duration
+}
+
+// DefaultRetryAfterConfig suggests retrying after one to three seconds.
+func DefaultRetryAfterConfig() RetryAfterConfig {
+	return RetryAfterConfig{Base: time.Second, Jitter: 2 * time.Second}
+}
+
+// delay returns Base plus a random share of Jitter.
+func (c RetryAfterConfig) delay() time.Duration {
+	if c.Jitter <= 0 {
+		return c.Base
+	}
+	return c.Base + rand.N(c.Jitter)
+}
+
+// RetryAfterMiddleware attaches a Retry-After header, in whole seconds, to
+// 429 and 503 responses. A Retry-After set further in, such as the rate
+// limiter's wait for its next token, is kept; it is only renamed to the
+// canonical Retry-After if a handler set it as retry-after or similar.
+func RetryAfterMiddleware(cfg RetryAfterConfig) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			response, err := next(ctx, request)
+			if response.StatusCode != 429 && response.StatusCode != 503 {
+				return response, err
+			}
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+
+			if existing := takeHeader(response.Headers, "Retry-After"); existing != "" {
+				response.Headers["Retry-After"] = existing
+				return response, err
+			}
+			setRetryAfter(response.Headers, cfg.delay())
+			return response, err
+		}
+	}
+}
+
+// setRetryAfter sets Retry-After to wait, as formatted by retryAfterValue.
+func setRetryAfter(headers map[string]string, wait time.Duration) {
+	takeHeader(headers, "Retry-After")
+	headers["Retry-After"] = retryAfterValue(wait)
+}
+
+// retryAfterValue renders wait rounded up to whole seconds, and never below
+// one so clients do not retry immediately.
+func retryAfterValue(wait time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(wait.Seconds()))))
+}
+
+// takeHeader removes every case variant of the named header from headers
+// and returns the first non-empty value found, preferring the exact name.
+func takeHeader(headers map[string]string, name string) string {
+	value := headers[name]
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			if value == "" {
+				value = v
+			}
+			delete(headers, k)
+		}
+	}
+	return value
+}
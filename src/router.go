@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// HandlerFunc handles a single API Gateway request. Path parameters extracted
+// by the Router are available in request.PathParameters.
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+
+type route struct {
+	method   string
+	segments []string
+	handler  HandlerFunc
+	timeout  time.Duration
+	schema   *jsonschema.Schema
+	doc      routeDoc
+}
+
+// RouteOption configures a route registered with Router.Handle.
+type RouteOption func(*route)
+
+// WithTimeout bounds the route's handler to d, independently of the Lambda
+// timeout, answering a 504 when it runs over. The handler's context is
+// cancelled at the deadline, so downstream calls made with it are abandoned
+// too.
+func WithTimeout(d time.Duration) RouteOption {
+	return func(rt *route) { rt.timeout = d }
+}
+
+// Router dispatches requests to handlers by HTTP method and path pattern.
+type Router struct {
+	routes []route
+
+	// NotFoundHandler, when set, answers requests whose path matches no
+	// route in place of the default JSON 404.
+	NotFoundHandler HandlerFunc
+}
+
+// NewRouter returns an empty Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers h for method and pathPattern. Segments wrapped in braces,
+// such as /users/{id}, match any single path segment and are passed to the
+// handler by name. Options such as WithRequestBody describe the route for
+// OpenAPISpec; WithTimeout bounds its handler, and WithSchema validates its
+// request body.
+func (r *Router) Handle(method, pathPattern string, h HandlerFunc, opts ...RouteOption) {
+	rt := route{
+		method:   strings.ToUpper(method),
+		segments: splitPath(pathPattern),
+		handler:  h,
+	}
+	for _, opt := range opts {
+		opt(&rt)
+	}
+	if rt.schema != nil {
+		rt.handler = SchemaMiddleware(rt.schema)(rt.handler)
+	}
+	if rt.timeout > 0 {
+		rt.handler = routeTimeout(rt.handler, rt.timeout)
+	}
+	r.routes = append(r.routes, rt)
+}
+
+// Dispatch routes request to the matching handler. It returns 404, or runs
+// NotFoundHandler, when no route matches the path, and 405 with an Allow
+// header listing the path's methods when the path matches but the method
+// does not.
+// OPTIONS requests for a known path without an explicit OPTIONS route are
+// answered with a 204 whose Allow header lists the path's methods.
+func (r *Router) Dispatch(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	segments := splitPath(request.Path)
+	method := strings.ToUpper(request.HTTPMethod)
+
+	var allowed []string
+	for _, rt := range r.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method != method {
+			allowed = appendUnique(allowed, rt.method)
+			continue
+		}
+		request.PathParameters = params
+		return rt.handler(ctx, request)
+	}
+
+	if len(allowed) > 0 {
+		return methodNotAllowed(method, allowed), nil
+	}
+
+	if r.NotFoundHandler != nil {
+		return r.NotFoundHandler(ctx, request)
+	}
+	return Error(404, "Not found"), nil
+}
+
+// methodNotAllowed answers a request whose method is not in allowed: a 204
+// listing allowed, plus OPTIONS, for an OPTIONS request, and otherwise a 405
+// with an Allow header.
+func methodNotAllowed(method string, allowed []string) Response {
+	if method == "OPTIONS" {
+		return Response{
+			StatusCode: 204,
+			Headers: map[string]string{
+				"Allow": strings.Join(appendUnique(allowed, "OPTIONS"), ", "),
+			},
+		}
+	}
+
+	response := Error(405, "Method not allowed")
+	response.Headers["Allow"] = strings.Join(allowed, ", ")
+	return response
+}
+
+func (rt route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, pattern := range rt.segments {
+		if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[pattern[1:len(pattern)-1]] = segments[i]
+			continue
+		}
+		if pattern != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
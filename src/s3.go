@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// S3ObjectFunc processes one object named in an S3 notification.
+type S3ObjectFunc func(ctx context.Context, bucket, key string) error
+
+// S3Handler adapts process to an S3-triggered Lambda. Every record is
+// processed even if an earlier one fails or panics; the invocation fails, and S3
+// retries the whole event, only if at least one record failed.
+func S3Handler(process S3ObjectFunc) func(context.Context, events.S3Event) error {
+	return func(ctx context.Context, event events.S3Event) error {
+		markInvocation()
+		ctx = withRequestIDs(ctx, "")
+
+		var errs []error
+		for _, record := range event.Records {
+			bucket := record.S3.Bucket.Name
+			key, err := s3ObjectKey(record.S3.Object.Key)
+			if err == nil {
+				err = processS3Object(ctx, process, bucket, key)
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "processing S3 object",
+					"eventName", record.EventName,
+					"bucket", bucket,
+					"key", record.S3.Object.Key,
+					"error", err,
+				)
+				errs = append(errs, fmt.Errorf("s3://%s/%s: %w", bucket, record.S3.Object.Key, err))
+			}
+		}
+
+		logger.InfoContext(ctx, "s3 batch", "records", len(event.Records), "failed", len(errs))
+		return errors.Join(errs...)
+	}
+}
+
+// processS3Object runs process for one object, turning a panic into that
+// object's error so the rest of the event is still processed.
+func processS3Object(ctx context.Context, process S3ObjectFunc, bucket, key string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorContext(ctx, "panic processing S3 object",
+				"bucket", bucket,
+				"key", key,
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()),
+			)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return process(ctx, bucket, key)
+}
+
+// s3ObjectKey decodes a key from an S3 notification. S3 form-encodes keys
+// there, so "my file+1.txt" arrives as "my+file%2B1.txt": a plus is a space
+// and a literal plus is %2B. url.PathUnescape would leave the plus alone.
+func s3ObjectKey(raw string) (string, error) {
+	key, err := url.QueryUnescape(raw)
+	if err != nil {
+		return "", fmt.Errorf("decoding object key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestS3HandlerRecoversPanics(t *testing.T) {
+	var processed []string
+	h := S3Handler(func(ctx context.Context, bucket, key string) error {
+		if key == "bad.csv" {
+			panic("nil map")
+		}
+		processed = append(processed, key)
+		return nil
+	})
+
+	var event events.S3Event
+	for _, key := range []string{"a.csv", "bad.csv", "my+file%2B1.csv"} {
+		record := events.S3EventRecord{}
+		record.S3.Bucket.Name = "uploads"
+		record.S3.Object.Key = key
+		event.Records = append(event.Records, record)
+	}
+
+	err := h(context.Background(), event)
+	if err == nil || !strings.Contains(err.Error(), "s3://uploads/bad.csv: panic: nil map") {
+		t.Errorf("error = %v, want the panicking object's failure", err)
+	}
+	if !reflect.DeepEqual(processed, []string{"a.csv", "my file+1.csv"}) {
+		t.Errorf("processed %q, want every other object", processed)
+	}
+}
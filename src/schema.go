@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaFS holds the JSON Schema documents loaded at startup.
+//
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+// SchemaSet holds compiled JSON Schemas by file name, such as
+// "message.json".
+type SchemaSet map[string]*jsonschema.Schema
+
+// LoadSchemas compiles every .json file in dir of fsys. The files are
+// registered together before any is compiled, so a $ref may name another
+// file by relative path, as in "common.json#/$defs/text"; references outside
+// the set are not fetched and fail to compile.
+func LoadSchemas(fsys fs.FS, dir string) (SchemaSet, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := jsonschema.UnmarshalJSON(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing schema %s: %w", name, err)
+		}
+		if err := compiler.AddResource(schemaURL(name), doc); err != nil {
+			return nil, fmt.Errorf("adding schema %s: %w", name, err)
+		}
+	}
+
+	set := make(SchemaSet, len(names))
+	for _, name := range names {
+		schema, err := compiler.Compile(schemaURL(name))
+		if err != nil {
+			return nil, fmt.Errorf("compiling schema %s: %w", name, err)
+		}
+		set[path.Base(name)] = schema
+	}
+	return set, nil
+}
+
+// schemaURL gives each file an absolute URL for resolving references.
+func schemaURL(name string) string {
+	return "file:///" + strings.TrimPrefix(name, "/")
+}
+
+// MustGet returns the schema compiled from name. It panics when there is
+// none, since a misnamed schema would otherwise leave the route unvalidated.
+func (s SchemaSet) MustGet(name string) *jsonschema.Schema {
+	schema, ok := s[name]
+	if !ok {
+		panic("no JSON schema named " + name)
+	}
+	return schema
+}
+
+// WithSchema validates the route's JSON request body against schema before
+// the handler runs.
+func WithSchema(schema *jsonschema.Schema) RouteOption {
+	return func(rt *route) { rt.schema = schema }
+}
+
+// SchemaMiddleware rejects requests whose JSON body does not match schema
+// with a 422 listing each failure as a field error, where the field is the
+// JSON Pointer to the offending value, such as "/message". Non-JSON or
+// malformed bodies get the same 415 and 400 errors as BindJSON.
+func SchemaMiddleware(schema *jsonschema.Schema) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			var doc interface{}
+			if err := bindJSON(request, &doc, false); err != nil {
+				return Response{}, err
+			}
+
+			err := schema.Validate(doc)
+			var validationErr *jsonschema.ValidationError
+			if errors.As(err, &validationErr) {
+				return ValidationError(schemaFieldErrors(validationErr)), nil
+			}
+			if err != nil {
+				return Response{}, fmt.Errorf("validating request body: %w", err)
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// schemaPrinter renders validation messages in English.
+var schemaPrinter = message.NewPrinter(language.English)
+
+// jsonPointerEscaper escapes a reference token for a JSON Pointer.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// schemaFieldErrors flattens a validation error to its leaf failures, the
+// ones naming a keyword such as minLength rather than a $ref or subschema
+// that failed because of them.
+func schemaFieldErrors(err *jsonschema.ValidationError) []FieldError {
+	if len(err.Causes) > 0 {
+		var fieldErrs []FieldError
+		for _, cause := range err.Causes {
+			fieldErrs = append(fieldErrs, schemaFieldErrors(cause)...)
+		}
+		return fieldErrs
+	}
+
+	var pointer strings.Builder
+	for _, token := range err.InstanceLocation {
+		pointer.WriteString("/" + jsonPointerEscaper.Replace(token))
+	}
+	field := pointer.String()
+	if field == "" {
+		field = "/"
+	}
+	return []FieldError{{Field: field, Message: err.ErrorKind.LocalizedString(schemaPrinter)}}
+}
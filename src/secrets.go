@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// DefaultSecretsRefreshInterval is how often a cached secret is refetched
+// when SECRETS_REFRESH_INTERVAL is unset.
+const DefaultSecretsRefreshInterval = 5 * time.Minute
+
+// SecretsLoader caches a JSON secret from Secrets Manager. The secret is
+// fetched once at startup; afterwards getters refetch it when it is older
+// than the refresh interval so long-lived containers pick up rotations.
+// Lambda freezes idle containers, so refreshing on access is more reliable
+// than a background timer.
+type SecretsLoader struct {
+	client   *secretsmanager.Client
+	secretID string
+	refresh  time.Duration
+
+	mu      sync.RWMutex
+	values  map[string]interface{}
+	fetched time.Time
+}
+
+// NewSecretsLoader fetches secretID and returns a loader caching it. It
+// fails if the initial fetch fails, so the function never starts serving
+// with empty credentials.
+func NewSecretsLoader(ctx context.Context, client *secretsmanager.Client, secretID string, refresh time.Duration) (*SecretsLoader, error) {
+	s := &SecretsLoader{client: client, secretID: secretID, refresh: refresh}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSecretsLoader builds the loader configured by cfg, or returns nil when
+// no secret is configured.
+func newSecretsLoader(ctx context.Context, cfg Config) (*SecretsLoader, error) {
+	if cfg.SecretID == "" {
+		return nil, nil
+	}
+
+	client, err := awsClients.SecretsManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecretsLoader(ctx, client, cfg.SecretID, cfg.SecretsRefreshInterval)
+}
+
+func (s *SecretsLoader) load(ctx context.Context) error {
+	out, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(s.secretID),
+	})
+	if err != nil {
+		return fmt.Errorf("fetching secret %s: %w", s.secretID, err)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.ToString(out.SecretString)), &values); err != nil {
+		// Do not wrap err: its message can quote the secret.
+		return fmt.Errorf("secret %s is not a JSON object", s.secretID)
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.fetched = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// ping checks that the secret is still reachable. It uses DescribeSecret,
+// which does not fetch or decrypt the value.
+func (s *SecretsLoader) ping(ctx context.Context) error {
+	_, err := s.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(s.secretID),
+	})
+	return err
+}
+
+// value returns the raw value for key, refreshing a stale cache first. A
+// failed refresh keeps serving the previous value.
+func (s *SecretsLoader) value(ctx context.Context, key string) (interface{}, bool) {
+	s.mu.RLock()
+	stale := s.refresh > 0 && time.Since(s.fetched) > s.refresh
+	s.mu.RUnlock()
+
+	if stale {
+		if err := s.load(ctx); err != nil {
+			logger.WarnContext(ctx, "refreshing secret", "secretId", s.secretID, "error", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// String returns the string value for key.
+func (s *SecretsLoader) String(ctx context.Context, key string) (string, bool) {
+	v, ok := s.value(ctx, key)
+	if !ok {
+		return "", false
+	}
+	str, ok := v.(string)
+	return str, ok
+}
+
+// Int returns the integer value for key, accepting JSON numbers and
+// numeric strings.
+func (s *SecretsLoader) Int(ctx context.Context, key string) (int, bool) {
+	v, ok := s.value(ctx, key)
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case float64:
+		return int(n), n == float64(int(n))
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Bool returns the boolean value for key, accepting JSON booleans and
+// strings such as "true".
+func (s *SecretsLoader) Bool(ctx context.Context, key string) (bool, bool) {
+	v, ok := s.value(ctx, key)
+	if !ok {
+		return false, false
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		parsed, err := strconv.ParseBool(b)
+		return parsed, err == nil
+	default:
+		return false, false
+	}
+}
+
+// StringMap returns a JSON object value for key whose members are all strings.
+func (s *SecretsLoader) StringMap(ctx context.Context, key string) (map[string]string, bool) {
+	v, ok := s.value(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	values := make(map[string]string, len(obj))
+	for k, raw := range obj {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, false
+		}
+		values[k] = str
+	}
+	return values, true
+}
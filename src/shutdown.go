@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds the shutdown hooks. Lambda sends SIGKILL
+// about 500ms after SIGTERM, so the hooks must finish well within that.
+const DefaultShutdownTimeout = 400 * time.Millisecond
+
+// ShutdownFunc releases a resource when the container shuts down. It should
+// return promptly once ctx is done.
+type ShutdownFunc func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	fn   ShutdownFunc
+}
+
+var shutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// OnShutdown registers fn to run, under name, when Lambda signals that the
+// container is shutting down.
+func OnShutdown(name string, fn ShutdownFunc) {
+	shutdownHooks.mu.Lock()
+	defer shutdownHooks.mu.Unlock()
+	shutdownHooks.hooks = append(shutdownHooks.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// shutdown runs every registered hook in parallel and returns once they have
+// all finished or timeout has passed, whichever is first. Hook failures are
+// logged; nothing can be retried this late.
+func shutdown(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shutdownHooks.mu.Lock()
+	hooks := append([]shutdownHook(nil), shutdownHooks.hooks...)
+	shutdownHooks.mu.Unlock()
+
+	logger.Info("shutting down", "hooks", len(hooks))
+
+	var wg sync.WaitGroup
+	for _, hook := range hooks {
+		wg.Add(1)
+		go func(hook shutdownHook) {
+			defer wg.Done()
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logger.Error("shutdown hook panicked", "hook", hook.name, "panic", fmt.Sprint(recovered))
+				}
+			}()
+			if err := hook.fn(ctx); err != nil {
+				logger.Error("shutdown hook failed", "hook", hook.name, "error", err)
+			}
+		}(hook)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warn("shutdown timed out", "timeout", timeout.String())
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SQSMessageFunc processes a single SQS message. Returning an error marks
+// just that message as failed.
+type SQSMessageFunc func(ctx context.Context, message events.SQSMessage) error
+
+// SQSHandler adapts process to an SQS-triggered Lambda. Records are processed
+// in order and every failed one, including a panicking one, is reported in
+// BatchItemFailures, so SQS redelivers only those messages. This needs
+// ReportBatchItemFailures enabled on the event source mapping; without it a
+// partial failure is treated as success.
+func SQSHandler(process SQSMessageFunc) func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		markInvocation()
+		ctx = withRequestIDs(ctx, "")
+
+		var response events.SQSEventResponse
+		for _, record := range event.Records {
+			if err := processSQSRecord(ctx, process, record); err != nil {
+				logger.ErrorContext(ctx, "processing SQS message",
+					"messageId", record.MessageId,
+					"error", err,
+				)
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: record.MessageId,
+				})
+			}
+		}
+
+		logger.InfoContext(ctx, "sqs batch",
+			"records", len(event.Records),
+			"failed", len(response.BatchItemFailures),
+		)
+		return response, nil
+	}
+}
+
+func processSQSRecord(ctx context.Context, process SQSMessageFunc, record events.SQSMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorContext(ctx, "panic processing SQS message",
+				"messageId", record.MessageId,
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()),
+			)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return process(ctx, record)
+}
+
+// processMessageFromSQS runs the POST /api/{name} logic for a queued message.
+// The body is a MessageRequest and the optional "name" message attribute
+// stands in for the path parameter.
+func processMessageFromSQS(ctx context.Context, record events.SQSMessage) error {
+	var message MessageRequest
+	if err := json.Unmarshal([]byte(record.Body), &message); err != nil {
+		return fmt.Errorf("decoding message body: %w", err)
+	}
+	if fieldErrs := Validate(message); len(fieldErrs) > 0 {
+		return fmt.Errorf("invalid message: %s %s", fieldErrs[0].Field, fieldErrs[0].Message)
+	}
+
+	var name string
+	if attr, ok := record.MessageAttributes["name"]; ok && attr.StringValue != nil {
+		name = *attr.StringValue
+	}
+	if _, err := processMessage(ctx, name, message); err != nil {
+		return err
+	}
+	return nil
+}
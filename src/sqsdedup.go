@@ -0,0 +1,206 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DedupStore remembers which SQS messages have been processed.
+type DedupStore interface {
+	// Seen reports whether key was marked within the window.
+	Seen(ctx context.Context, key string) (bool, error)
+	// Mark records key as processed for the window.
+	Mark(ctx context.Context, key string) error
+}
+
+// maxDedupEntries bounds the in-memory store; beyond it, the oldest keys are
+// forgotten first.
+const maxDedupEntries = 10000
+
+// MemoryDedupStore keeps keys in the container's memory, so it only catches
+// redeliveries that reach the same container.
+type MemoryDedupStore struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	order *list.List // of *dedupEntry, oldest first
+	keys  map[string]*list.Element
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemoryDedupStore returns a store that remembers keys for window.
+func NewMemoryDedupStore(window time.Duration) *MemoryDedupStore {
+	return &MemoryDedupStore{window: window, order: list.New(), keys: map[string]*list.Element{}}
+}
+
+// Seen reports whether key was marked within the window.
+func (s *MemoryDedupStore) Seen(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.keys[key]
+	return ok && time.Now().Before(el.Value.(*dedupEntry).expires), nil
+}
+
+// Mark records key as processed for the window.
+func (s *MemoryDedupStore) Mark(_ context.Context, key string) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.keys[key]; ok {
+		s.order.Remove(el)
+	}
+	s.keys[key] = s.order.PushBack(&dedupEntry{key: key, expires: now.Add(s.window)})
+
+	// Every key lives for the same window, so the oldest expire first.
+	for el := s.order.Front(); el != nil; el = s.order.Front() {
+		entry := el.Value.(*dedupEntry)
+		if s.order.Len() <= maxDedupEntries && now.Before(entry.expires) {
+			break
+		}
+		s.order.Remove(el)
+		delete(s.keys, entry.key)
+	}
+	return nil
+}
+
+// DynamoDBDedupStore keeps keys in a DynamoDB table keyed on the string
+// attribute "id", with "expiresAt" as its TTL attribute, so redeliveries are
+// caught across containers.
+type DynamoDBDedupStore struct {
+	client *dynamodb.Client
+	table  string
+	window time.Duration
+}
+
+// NewDynamoDBDedupStore returns a store that remembers keys in table for
+// window.
+func NewDynamoDBDedupStore(client *dynamodb.Client, table string, window time.Duration) *DynamoDBDedupStore {
+	return &DynamoDBDedupStore{client: client, table: table, window: window}
+}
+
+// Seen reports whether key was marked within the window.
+func (s *DynamoDBDedupStore) Seen(ctx context.Context, key string) (bool, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("reading dedup key: %w", err)
+	}
+
+	// DynamoDB deletes expired items lazily, so check the expiry too.
+	expires, ok := out.Item["expiresAt"].(*types.AttributeValueMemberN)
+	if !ok {
+		return false, nil
+	}
+	unix, _ := strconv.ParseInt(expires.Value, 10, 64)
+	return time.Now().Unix() < unix, nil
+}
+
+// Mark records key as processed for the window.
+func (s *DynamoDBDedupStore) Mark(ctx context.Context, key string) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			"id":        &types.AttributeValueMemberS{Value: key},
+			"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(s.window).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("writing dedup key: %w", err)
+	}
+	return nil
+}
+
+// sqsDedup is the deduplicator configured by main, or nil when SQS
+// deduplication is off.
+var sqsDedup *SQSDeduplicator
+
+// SQSDeduplicator skips SQS messages that were already processed, since SQS
+// delivers at least once.
+type SQSDeduplicator struct {
+	store DedupStore
+	// attribute names a message attribute holding the dedup key; the
+	// message ID is used when it is empty or the attribute is absent.
+	attribute string
+}
+
+// NewSQSDeduplicator returns a deduplicator that records keys in store.
+func NewSQSDeduplicator(store DedupStore, attribute string) *SQSDeduplicator {
+	return &SQSDeduplicator{store: store, attribute: attribute}
+}
+
+// newSQSDeduplicator builds the deduplicator configured by cfg, or returns
+// nil when no dedup window is set.
+func newSQSDeduplicator(ctx context.Context, cfg Config) (*SQSDeduplicator, error) {
+	if cfg.SQSDedupWindow <= 0 {
+		return nil, nil
+	}
+	if cfg.SQSDedupBackend != "dynamodb" {
+		return NewSQSDeduplicator(NewMemoryDedupStore(cfg.SQSDedupWindow), cfg.SQSDedupAttribute), nil
+	}
+
+	client, err := awsClients.DynamoDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := NewDynamoDBDedupStore(client, cfg.SQSDedupTable, cfg.SQSDedupWindow)
+	return NewSQSDeduplicator(store, cfg.SQSDedupAttribute), nil
+}
+
+// Wrap returns process with duplicates skipped: a message whose key was
+// seen within the window is acknowledged without running process. A key is
+// only marked once process succeeds, so a failed message is retried in
+// full. If the store cannot be read the message is processed anyway, since
+// a repeat is safer than a lost message. A nil deduplicator returns process
+// unchanged.
+func (d *SQSDeduplicator) Wrap(process SQSMessageFunc) SQSMessageFunc {
+	if d == nil {
+		return process
+	}
+	return func(ctx context.Context, message events.SQSMessage) error {
+		key := d.key(message)
+		seen, err := d.store.Seen(ctx, key)
+		if err != nil {
+			logger.ErrorContext(ctx, "checking SQS dedup key", "messageId", message.MessageId, "error", err)
+		}
+		if seen {
+			logger.InfoContext(ctx, "skipping duplicate SQS message", "messageId", message.MessageId, "dedupKey", key)
+			return nil
+		}
+
+		if err := process(ctx, message); err != nil {
+			return err
+		}
+		if err := d.store.Mark(ctx, key); err != nil {
+			logger.ErrorContext(ctx, "recording SQS dedup key", "messageId", message.MessageId, "error", err)
+		}
+		return nil
+	}
+}
+
+func (d *SQSDeduplicator) key(message events.SQSMessage) string {
+	if d.attribute != "" {
+		if attr, ok := message.MessageAttributes[d.attribute]; ok && attr.StringValue != nil && *attr.StringValue != "" {
+			return *attr.StringValue
+		}
+	}
+	return message.MessageId
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// StreamHandler writes a response body incrementally to w instead of
+// returning it whole, for payloads too large or too slow to buffer. w is a
+// *StreamWriter, through which the status and headers can be set before the
+// first write.
+type StreamHandler func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error
+
+// StreamWriter sends a streamed response. The status and headers make up
+// the prelude the runtime sends ahead of the body, so they are fixed by the
+// first Write, or by the handler returning; changes after that are ignored.
+type StreamWriter struct {
+	pipe *io.PipeWriter
+
+	mu         sync.Mutex
+	statusCode int
+	headers    map[string]string
+	cookies    []string
+	committed  bool
+
+	// ready is closed once the prelude is fixed and copied into prelude, or
+	// into err if it was fixed by the handler failing without writing.
+	ready   chan struct{}
+	prelude events.LambdaFunctionURLStreamingResponse
+	err     error
+}
+
+// SetHeader sets a response header until the first write.
+func (w *StreamWriter) SetHeader(name, value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.committed {
+		w.headers[name] = value
+	}
+}
+
+// AddCookie adds a Set-Cookie to the response until the first write.
+func (w *StreamWriter) AddCookie(cookie http.Cookie) {
+	w.addSetCookie(cookie.String())
+}
+
+// addSetCookie adds an already serialized Set-Cookie value.
+func (w *StreamWriter) addSetCookie(value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.committed {
+		w.cookies = append(w.cookies, value)
+	}
+}
+
+// WriteHeader sets the status code, 200 by default, until the first write.
+func (w *StreamWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.committed {
+		w.statusCode = statusCode
+	}
+}
+
+// Write sends p to the client, fixing the prelude on the first call. It
+// blocks until the runtime has read p, so a slow client slows the handler
+// rather than filling memory.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	w.commit(nil)
+	return w.pipe.Write(p)
+}
+
+// commit fixes the prelude, recording err when the handler failed before
+// writing anything. It reports whether this call fixed it.
+func (w *StreamWriter) commit(err error) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.committed {
+		return false
+	}
+	w.committed = true
+	w.prelude = events.LambdaFunctionURLStreamingResponse{
+		StatusCode: w.statusCode,
+		Headers:    w.headers,
+		Cookies:    w.cookies,
+	}
+	w.err = err
+	close(w.ready)
+	return true
+}
+
+// FunctionURLStreamHandler adapts h to a Lambda Function URL whose invoke
+// mode is RESPONSE_STREAM; API Gateway does not stream Lambda responses, so
+// only Function URLs can use it. The request is normalized as for
+// FunctionURLHandler.
+//
+// h runs while the body is being sent. An error or panic before the first
+// write is answered with the usual JSON error response, plus any headers h
+// set that it does not define; after it, the status has been sent, so the
+// error is logged and the stream is cut short.
+func FunctionURLStreamHandler(h StreamHandler) func(context.Context, events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	return func(ctx context.Context, request events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+		markInvocation()
+		ctx = withRequestIDs(ctx, request.RequestContext.RequestID)
+		pr, pw := io.Pipe()
+		w := &StreamWriter{pipe: pw, statusCode: 200, headers: map[string]string{}, ready: make(chan struct{})}
+
+		go func() {
+			err := runStreamHandler(ctx, h, fromFunctionURLRequest(request), w)
+			if w.commit(err) || err == nil {
+				pw.Close()
+				return
+			}
+			logger.ErrorContext(ctx, "streaming response failed", "path", request.RawPath, "error", err)
+			pw.CloseWithError(err)
+		}()
+
+		<-w.ready
+		if w.err != nil {
+			response := ErrorResponse(w.err)
+			for k, v := range w.prelude.Headers {
+				if headerValue(response.Headers, k) == "" {
+					response.Headers[k] = v
+				}
+			}
+			logError(ctx, response.StatusCode, w.err)
+			return toFunctionURLStreamingResponse(response, strings.NewReader(response.Body)), nil
+		}
+
+		response := w.prelude
+		response.Body = pr
+		return &response, nil
+	}
+}
+
+// StreamResponses adapts h, a buffered handler such as the router chain, to
+// a StreamHandler, so a whole API can be served from a RESPONSE_STREAM
+// Function URL: streamed responses may exceed the 6 MB limit on buffered
+// ones, and the client starts receiving the body without waiting for the
+// runtime to relay it whole. A base64-encoded body is decoded first, since a
+// stream carries raw bytes.
+func StreamResponses(h HandlerFunc) StreamHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+		response, err := h(ctx, request)
+		if err != nil {
+			return err
+		}
+		body := []byte(response.Body)
+		if response.IsBase64Encoded {
+			if body, err = base64.StdEncoding.DecodeString(response.Body); err != nil {
+				return fmt.Errorf("decoding base64 response body: %w", err)
+			}
+		}
+
+		if sw, ok := w.(*StreamWriter); ok {
+			cookies, headers := responseCookies(response)
+			for k, v := range headers {
+				sw.SetHeader(k, v)
+			}
+			for _, cookie := range cookies {
+				sw.addSetCookie(cookie)
+			}
+			sw.WriteHeader(response.StatusCode)
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		_, err = w.Write(body)
+		return err
+	}
+}
+
+// runStreamHandler runs h, turning a panic into an error so the stream is
+// closed rather than the process crashing.
+func runStreamHandler(ctx context.Context, h StreamHandler, request events.APIGatewayProxyRequest, w *StreamWriter) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.ErrorContext(ctx, "recovered from panic", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return h(ctx, request, w)
+}
+
+func toFunctionURLStreamingResponse(response Response, body io.Reader) *events.LambdaFunctionURLStreamingResponse {
+	cookies, headers := responseCookies(response)
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: response.StatusCode,
+		Headers:    headers,
+		Cookies:    cookies,
+		Body:       body,
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func TestFunctionURLStreamHandlerChunks(t *testing.T) {
+	chunks := []string{"first\n", "second\n", "third\n"}
+	// The handler waits after its first chunk until the test has read it,
+	// so the test fails unless chunks reach the client as they are written.
+	firstRead := make(chan struct{})
+	h := FunctionURLStreamHandler(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+		sw := w.(*StreamWriter)
+		sw.SetHeader("Content-Type", "application/x-ndjson")
+		sw.AddCookie(http.Cookie{Name: "session", Value: "abc"})
+		sw.WriteHeader(206)
+		for i, chunk := range chunks {
+			if _, err := io.WriteString(w, chunk); err != nil {
+				return err
+			}
+			if i == 0 {
+				<-firstRead
+			}
+		}
+		// Changes after the first write are ignored.
+		sw.WriteHeader(500)
+		sw.SetHeader("X-Late", "1")
+		return nil
+	})
+
+	response, err := h(context.Background(), events.LambdaFunctionURLRequest{RawPath: "/export"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 206 || response.Headers["Content-Type"] != "application/x-ndjson" {
+		t.Errorf("prelude = %d %v, want 206 with the handler's Content-Type", response.StatusCode, response.Headers)
+	}
+	if _, late := response.Headers["X-Late"]; late {
+		t.Error("a header set after the first write was sent")
+	}
+	if !reflect.DeepEqual(response.Cookies, []string{"session=abc"}) {
+		t.Errorf("cookies = %q", response.Cookies)
+	}
+
+	first := make([]byte, len(chunks[0]))
+	if _, err := io.ReadFull(response.Body, first); err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != chunks[0] {
+		t.Errorf("first chunk = %q, want %q", first, chunks[0])
+	}
+	close(firstRead)
+
+	rest, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(chunks[1:], ""); string(rest) != want {
+		t.Errorf("rest of body = %q, want %q", rest, want)
+	}
+}
+
+func TestFunctionURLStreamHandlerErrors(t *testing.T) {
+	t.Run("before first write", func(t *testing.T) {
+		h := FunctionURLStreamHandler(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+			w.(*StreamWriter).SetHeader("Retry-After", "5")
+			return ErrAtCapacity
+		})
+		response, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		if response.StatusCode != 503 || response.Headers["Retry-After"] != "5" || !strings.Contains(string(body), "at_capacity") {
+			t.Errorf("got %d %v %s, want a 503 at_capacity error keeping Retry-After", response.StatusCode, response.Headers, body)
+		}
+	})
+
+	t.Run("after first write", func(t *testing.T) {
+		h := FunctionURLStreamHandler(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+			io.WriteString(w, "partial")
+			return errors.New("source went away")
+		})
+		response, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(response.Body)
+		if response.StatusCode != 200 || string(body) != "partial" || err == nil {
+			t.Errorf("got %d %q %v, want a 200 cut short with an error", response.StatusCode, body, err)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		h := FunctionURLStreamHandler(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+			panic("boom")
+		})
+		response, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+		if err != nil || response.StatusCode != 500 {
+			t.Errorf("got %v, %v, want a 500", response, err)
+		}
+	})
+}
+
+func TestStreamResponses(t *testing.T) {
+	h := FunctionURLStreamHandler(StreamResponses(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		response := Binary(201, "application/octet-stream", []byte{0, 1, 2, 255})
+		response.Headers["Set-Cookie"] = "a=1"
+		response.AddCookie(http.Cookie{Name: "b", Value: "2"})
+		return response, nil
+	}))
+	response, err := h(context.Background(), events.LambdaFunctionURLRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != 201 || response.Headers["Content-Type"] != "application/octet-stream" {
+		t.Errorf("prelude = %d %v", response.StatusCode, response.Headers)
+	}
+	if !reflect.DeepEqual(response.Cookies, []string{"a=1", "b=2"}) {
+		t.Errorf("cookies = %q", response.Cookies)
+	}
+	if !bytes.Equal(body, []byte{0, 1, 2, 255}) {
+		t.Errorf("body = %v, want the decoded bytes", body)
+	}
+}
+
+func TestWarmupHandlerKeepsStreaming(t *testing.T) {
+	stream := FunctionURLStreamHandler(func(ctx context.Context, request events.APIGatewayProxyRequest, w io.Writer) error {
+		_, err := io.WriteString(w, "chunk")
+		return err
+	})
+	h := lambda.NewHandler(WarmupHandler(DefaultWarmupConfig(), stream))
+
+	out, err := h.Invoke(context.Background(), []byte(`{"source": "serverless-plugin-warmup"}`))
+	if err != nil || string(out) != string(warmupResponse) {
+		t.Errorf("warmup = %s, %v, want %s", out, err, warmupResponse)
+	}
+
+	payload, _ := json.Marshal(events.LambdaFunctionURLRequest{RawPath: "/"})
+	out, err = h.Invoke(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A streamed response is the JSON prelude, eight NULs, then the body.
+	prelude, body, ok := bytes.Cut(out, make([]byte, 8))
+	if !ok || !bytes.HasPrefix(prelude, []byte(`{"statusCode":200`)) || string(body) != "chunk" {
+		t.Errorf("streamed response = %q", out)
+	}
+}
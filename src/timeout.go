@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultTimeoutMargin is how long before the Lambda deadline the handler
+// gives up, leaving time to return the 504 before the runtime kills it.
+const DefaultTimeoutMargin = 250 * time.Millisecond
+
+type handlerResult struct {
+	response  Response
+	err       error
+	recovered *goroutinePanic
+}
+
+// goroutinePanic carries a panic recovered on a worker goroutine, with the
+// stack captured there, to be re-raised on the caller's goroutine. The stack
+// of the re-raise would only show runUntilDone, so RecoverMiddleware logs
+// this one instead.
+type goroutinePanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *goroutinePanic) String() string { return fmt.Sprint(p.value) }
+
+// TimeoutMiddleware runs next in a goroutine and returns a 504 if the
+// context deadline, less margin, passes first.
+func TimeoutMiddleware(margin time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+			if deadline, ok := ctx.Deadline(); ok {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, deadline.Add(-margin))
+				defer cancel()
+			}
+			return runUntilDone(ctx, next, request)
+		}
+	}
+}
+
+// routeTimeout bounds h to timeout, for routes registered WithTimeout.
+func routeTimeout(h HandlerFunc, timeout time.Duration) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return runUntilDone(ctx, h, request)
+	}
+}
+
+// runUntilDone runs next in a goroutine and returns a 504 if ctx is done
+// first. The abandoned call's result is discarded; it sees a cancelled
+// context and should stop promptly.
+func runUntilDone(ctx context.Context, next HandlerFunc, request events.APIGatewayProxyRequest) (Response, error) {
+	start := time.Now()
+	// Buffered so the goroutine can always deliver and exit, even after the
+	// timeout branch has stopped listening.
+	done := make(chan handlerResult, 1)
+	go func() {
+		var result handlerResult
+		defer func() {
+			if r := recover(); r != nil {
+				result.recovered = &goroutinePanic{value: r, stack: debug.Stack()}
+			}
+			done <- result
+		}()
+		result.response, result.err = next(ctx, request)
+	}()
+
+	select {
+	case result := <-done:
+		if result.recovered != nil {
+			// Re-raise on the caller's goroutine so RecoverMiddleware sees it.
+			panic(result.recovered)
+		}
+		return result.response, result.err
+	case <-ctx.Done():
+		logger.ErrorContext(ctx, "handler timed out",
+			"elapsedMs", time.Since(start).Milliseconds(),
+			"error", ctx.Err(),
+		)
+		return Error(504, "Request timed out"), nil
+	}
+}
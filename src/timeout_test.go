@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	stopped := make(chan struct{})
+	h := TimeoutMiddleware(10 * time.Millisecond)(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		select {
+		case <-ctx.Done():
+			close(stopped)
+		case <-time.After(time.Second):
+		}
+		return JSON(200, nil), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	response, err := h(ctx, events.APIGatewayProxyRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 504 {
+		t.Errorf("status = %d, want 504", response.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("returned after %v, want before the 50ms deadline", elapsed)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("handler's context was not cancelled")
+	}
+}
+
+func TestTimeoutMiddlewareFastHandler(t *testing.T) {
+	h := TimeoutMiddleware(10 * time.Millisecond)(func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		return JSON(200, nil), nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if response, err := h(ctx, events.APIGatewayProxyRequest{}); err != nil || response.StatusCode != 200 {
+		t.Errorf("fast handler = %d, %v, want 200", response.StatusCode, err)
+	}
+}
+
+func explodingHandler(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+	panic("boom")
+}
+
+func TestTimeoutPanicKeepsWorkerStack(t *testing.T) {
+	var logs bytes.Buffer
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	h := Chain(explodingHandler, RecoverMiddleware, TimeoutMiddleware(DefaultTimeoutMargin))
+	response, err := h(context.Background(), events.APIGatewayProxyRequest{})
+	if err != nil || response.StatusCode != 500 {
+		t.Fatalf("got %d, %v; want a 500", response.StatusCode, err)
+	}
+
+	var entry struct {
+		Panic string `json:"panic"`
+		Stack string `json:"stack"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Panic != "boom" {
+		t.Errorf("logged panic = %q, want the handler's value", entry.Panic)
+	}
+	if !strings.Contains(entry.Stack, "explodingHandler") {
+		t.Errorf("logged stack does not reach the panicking handler:\n%s", entry.Stack)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// tracingEnabled reports whether the invocation is being traced by X-Ray.
+// Outside Lambda, or with tracing turned off, there is no trace header and
+// the tracing helpers fall through to plain calls.
+func tracingEnabled(ctx context.Context) bool {
+	if os.Getenv("AWS_XRAY_SDK_DISABLED") == "true" {
+		return false
+	}
+	traceHeader, _ := ctx.Value(xray.LambdaTraceHeaderKey).(string)
+	return traceHeader != ""
+}
+
+// TracingMiddleware records the request as an X-Ray subsegment annotated
+// with the method, path and status code so traces can be filtered. The
+// Lambda-owned segment cannot be annotated, hence the subsegment.
+func TracingMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error) {
+		if !tracingEnabled(ctx) {
+			return next(ctx, request)
+		}
+
+		ctx, seg := xray.BeginSubsegment(ctx, "handler")
+		response, err := next(ctx, request)
+
+		_ = seg.AddAnnotation("method", request.HTTPMethod)
+		_ = seg.AddAnnotation("path", request.Path)
+		_ = seg.AddAnnotation("statusCode", response.StatusCode)
+		seg.Close(err)
+		return response, err
+	}
+}
+
+// Segment runs fn inside an X-Ray subsegment called name, recording any
+// error it returns. Without tracing it simply calls fn.
+func Segment(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	if !tracingEnabled(ctx) {
+		return fn(ctx)
+	}
+	return xray.Capture(ctx, name, fn)
+}
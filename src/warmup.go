@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// WarmupConfig identifies synthetic warmer invocations: an event is a warmup
+// when its top-level Field equals Value. Only direct or scheduled invokes can
+// set top-level fields; API Gateway, Function URL and ALB events carry what
+// the client sent under headers and body, which are never consulted, so a
+// client cannot skip the handler by posing as a warmer. An empty Field or
+// Value disables the check.
+type WarmupConfig struct {
+	Field string
+	Value string
+}
+
+// DefaultWarmupConfig matches serverless-plugin-warmup's event.
+func DefaultWarmupConfig() WarmupConfig {
+	return WarmupConfig{
+		Field: "source",
+		Value: "serverless-plugin-warmup",
+	}
+}
+
+// warmupResponse is returned to warmers. It is a valid proxy response for
+// warmers that go through API Gateway.
+var warmupResponse = []byte(`{"statusCode":200,"body":""}`)
+
+type warmupHandler struct {
+	cfg  WarmupConfig
+	next lambda.Handler
+}
+
+// WarmupHandler answers warmup events with a bare 200 before they are decoded
+// as requests, so they never reach middleware, business logic, logs or
+// metrics. Every other event goes to handler, which is anything lambda.Start
+// accepts. The result is for lambda.Start too.
+func WarmupHandler(cfg WarmupConfig, handler interface{}) interface{} {
+	if stream, ok := handler.(func(context.Context, events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error)); ok {
+		return warmupStream(cfg, stream)
+	}
+	return warmupHandler{cfg: cfg, next: lambda.NewHandler(handler)}
+}
+
+// warmupStream is WarmupHandler for a FunctionURLStreamHandler. A
+// lambda.Handler cannot wrap it, since Invoke returns the response as bytes,
+// which would buffer the whole stream and lose its content type; the runtime
+// streams a returned io.Reader instead.
+func warmupStream(cfg WarmupConfig, next func(context.Context, events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error)) func(context.Context, json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		if cfg.isWarmup(payload) {
+			markInvocation()
+			return json.RawMessage(warmupResponse), nil
+		}
+		var request events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return next(ctx, request)
+	}
+}
+
+func (h warmupHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	if h.cfg.isWarmup(payload) {
+		// A warmer usually triggers the container's cold start; mark it so
+		// the next real request is not reported as cold.
+		markInvocation()
+		return warmupResponse, nil
+	}
+	return h.next.Invoke(ctx, payload)
+}
+
+func (c WarmupConfig) isWarmup(payload []byte) bool {
+	if c.Field == "" || c.Value == "" {
+		return false
+	}
+	var event map[string]json.RawMessage
+	if json.Unmarshal(payload, &event) != nil {
+		return false
+	}
+	var v string
+	return json.Unmarshal(event[c.Field], &v) == nil && v == c.Value
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestIsWarmup(t *testing.T) {
+	cfg := DefaultWarmupConfig()
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{"scheduled warmer", `{"source": "serverless-plugin-warmup"}`, true},
+		{"other source", `{"source": "aws.events"}`, false},
+		{"not an object", `"serverless-plugin-warmup"`, false},
+		{"header", `{"httpMethod": "POST", "headers": {"X-Lambda-Warmup": "1"}}`, false},
+		{"body field", `{"httpMethod": "POST", "body": "{\"source\": \"serverless-plugin-warmup\"}"}`, false},
+		{"nested field", `{"requestContext": {"source": "serverless-plugin-warmup"}}`, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.isWarmup([]byte(tt.payload)); got != tt.want {
+			t.Errorf("%s: isWarmup = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if (WarmupConfig{Field: "source"}).isWarmup([]byte(`{"source": ""}`)) {
+		t.Error("an empty Value matched")
+	}
+}
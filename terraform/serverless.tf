@@ -143,6 +143,12 @@ resource "aws_iam_role_policy_attachment" "lambda_basic_execution" {
   role       = aws_iam_role.lambda_role.name
 }
 
+# Allow the function to send X-Ray trace segments
+resource "aws_iam_role_policy_attachment" "lambda_xray" {
+  policy_arn = "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+  role       = aws_iam_role.lambda_role.name
+}
+
 # CloudWatch Log Group
 resource "aws_cloudwatch_log_group" "lambda_logs" {
   name              = "/aws/lambda/${local.function_name}"
@@ -165,6 +171,10 @@ resource "aws_lambda_function" "main" {
   timeout     = var.lambda_timeout
   memory_size = var.lambda_memory_size
 
+  tracing_config {
+    mode = "Active"
+  }
+
   environment {
     variables = merge(
       {
@@ -177,6 +187,7 @@ resource "aws_lambda_function" "main" {
 
   depends_on = [
     aws_iam_role_policy_attachment.lambda_basic_execution,
+    aws_iam_role_policy_attachment.lambda_xray,
     aws_cloudwatch_log_group.lambda_logs,
     aws_s3_object.lambda_package
   ]
@@ -189,6 +200,8 @@ resource "aws_api_gateway_rest_api" "main" {
   name        = "${local.function_name}-api"
   description = "API Gateway for ${var.project_name}"
 
+  binary_media_types = var.api_gateway_binary_media_types
+
   endpoint_configuration {
     types = ["REGIONAL"]
   }
@@ -252,6 +265,7 @@ resource "aws_api_gateway_deployment" "main" {
 
   triggers = {
     redeployment = sha1(jsonencode([
+      aws_api_gateway_rest_api.main.binary_media_types,
       aws_api_gateway_resource.proxy.id,
       aws_api_gateway_method.proxy.id,
       aws_api_gateway_method.proxy_root.id,
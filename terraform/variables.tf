@@ -46,6 +46,12 @@ variable "api_gateway_stage_name" {
   default     = "prod"
 }
 
+variable "api_gateway_binary_media_types" {
+  description = "Content types API Gateway treats as binary, decoding base64 responses such as images and gzip bodies"
+  type        = list(string)
+  default     = ["*/*"]
+}
+
 variable "lambda_environment_variables" {
   description = "Environment variables for Lambda function"
   type        = map(string)